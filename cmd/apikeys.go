@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"cruder/internal/audit"
+	"cruder/internal/config"
+	"cruder/internal/logging"
+	"cruder/internal/model"
+	"cruder/internal/repository"
+	"cruder/internal/service"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const apiKeysUsage = `usage: cruder apikeys <command> [flags]
+
+commands:
+  create  -name NAME -scopes users:read,users:write [-tenant SLUG] [-expires 720h]
+  list
+  rotate  -id ID [-overlap 24h]
+  revoke  -id ID
+`
+
+// runAPIKeys implements the "apikeys" subcommand. Logs, including the audit
+// trail, go to stderr so stdout only carries the command's output.
+func runAPIKeys(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, apiKeysUsage)
+		return 2
+	}
+
+	cfg, err := config.Load("config.yaml")
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+	logger, _, err := logging.New(logging.Options{Level: cfg.Logging.Level, Format: cfg.Logging.Format, Output: "stderr"})
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to set up logging: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = audit.WithActor(ctx, "cli:"+cliUser())
+
+	dbConn, err := repository.NewPostgresConnection(ctx, cfg.GetDSN(), logger, repository.PoolOptions{MaxOpenConns: 2}, repository.RetryOptions{})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer dbConn.Close()
+
+	dbRouter := repository.NewDBRouter(dbConn.DB(), nil, repository.RouterOptions{})
+	defer dbRouter.Close()
+
+	repositories := repository.NewRepository(dbRouter, logger, repository.Options{})
+	keys := service.NewAPIKeyService(repositories.APIKeys, repositories.Tenants, logger, service.APIKeyOptions{DatabaseKeys: true})
+	return apiKeysCommand(ctx, keys, args, stdout, stderr)
+}
+
+// apiKeysCommand runs one apikeys command against keys.
+func apiKeysCommand(ctx context.Context, keys service.APIKeyService, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("apikeys "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	switch args[0] {
+	case "create":
+		name := flags.String("name", "", "key name")
+		scopes := flags.String("scopes", "", "comma separated scopes: "+strings.Join(model.Scopes, ", "))
+		tenant := flags.String("tenant", "", "slug of the tenant the key is bound to, empty for any tenant")
+		expires := flags.Duration("expires", 0, "lifetime of the key, 0 for no expiry")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		var expiresAt *time.Time
+		if *expires > 0 {
+			at := time.Now().Add(*expires)
+			expiresAt = &at
+		}
+		rawKey, key, err := keys.Create(ctx, *name, *tenant, splitScopes(*scopes), expiresAt)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		return printCreated(stdout, rawKey, key)
+
+	case "list":
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		list, err := keys.List(ctx)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tKEY\tSCOPES\tTENANT\tEXPIRES\tLAST USED\tSTATUS")
+		for _, key := range list {
+			tenant := key.Tenant
+			if tenant == "" {
+				tenant = "*"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				key.ID, key.Name, key.Masked(), strings.Join(key.Scopes, ","), tenant,
+				formatTime(key.ExpiresAt), formatTime(key.LastUsedAt), keyStatus(key))
+		}
+		w.Flush()
+		return 0
+
+	case "rotate":
+		id := flags.Int("id", 0, "id of the key to rotate")
+		overlap := flags.Duration("overlap", 24*time.Hour, "how long the old key stays valid")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		rawKey, key, err := keys.Rotate(ctx, *id, *overlap)
+		if err != nil {
+			return cliError(stderr, err)
+		}
+		return printCreated(stdout, rawKey, key)
+
+	case "revoke":
+		id := flags.Int("id", 0, "id of the key to revoke")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if err := keys.Revoke(ctx, *id); err != nil {
+			return cliError(stderr, err)
+		}
+		fmt.Fprintf(stdout, "revoked api key %d\n", *id)
+		return 0
+
+	default:
+		fmt.Fprint(stderr, apiKeysUsage)
+		return 2
+	}
+}
+
+func printCreated(w io.Writer, rawKey string, key *model.APIKey) int {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(map[string]any{"key": rawKey, "api_key": key})
+	fmt.Fprintln(w, "store the key now, it cannot be shown again")
+	return 0
+}
+
+func cliError(w io.Writer, err error) int {
+	var validationErr *model.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fmt.Fprintf(w, "invalid input: %s\n", validationErr.Message)
+		return 2
+	case errors.Is(err, sql.ErrNoRows):
+		fmt.Fprintln(w, "api key not found")
+	default:
+		fmt.Fprintf(w, "error: %v\n", err)
+	}
+	return 1
+}
+
+func splitScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func keyStatus(key model.APIKey) string {
+	switch {
+	case key.Revoked():
+		return "revoked"
+	case key.Expired(time.Now()):
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
+func cliUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(os.Getuid())
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+)
+
+const cliRawKey = "crd_0123456789ab_secret"
+
+type fakeAPIKeyService struct {
+	name      string
+	tenant    string
+	scopes    []string
+	expiresAt *time.Time
+	overlap   time.Duration
+}
+
+func (f *fakeAPIKeyService) Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error) {
+	return nil, model.ErrInvalidAPIKey
+}
+
+func (f *fakeAPIKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	return []model.APIKey{{ID: 1, Name: "billing", Prefix: "0123456789ab", Scopes: []string{model.ScopeUsersRead}}}, nil
+}
+
+func (f *fakeAPIKeyService) Create(ctx context.Context, name, tenant string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error) {
+	if name == "" {
+		return "", nil, model.NewRuleValidationError("api_key_name_required", "name is required")
+	}
+	f.name, f.tenant, f.scopes, f.expiresAt = name, tenant, scopes, expiresAt
+	return cliRawKey, &model.APIKey{ID: 2, Name: name, Prefix: "0123456789ab", Scopes: scopes, Tenant: tenant}, nil
+}
+
+func (f *fakeAPIKeyService) Rotate(ctx context.Context, id int, overlap time.Duration) (string, *model.APIKey, error) {
+	if id != 1 {
+		return "", nil, sql.ErrNoRows
+	}
+	f.overlap = overlap
+	return cliRawKey, &model.APIKey{ID: 2, Name: "billing", Prefix: "0123456789ab"}, nil
+}
+
+func (f *fakeAPIKeyService) Revoke(ctx context.Context, id int) error {
+	if id != 1 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func runCommand(keys *fakeAPIKeyService, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := apiKeysCommand(context.Background(), keys, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestAPIKeysCommand_Create(t *testing.T) {
+	keys := &fakeAPIKeyService{}
+	code, stdout, _ := runCommand(keys, "create", "-name", "reporting", "-scopes", "users:read, users:write,", "-tenant", "acme", "-expires", "720h")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if keys.name != "reporting" || keys.tenant != "acme" || !slices.Equal(keys.scopes, []string{model.ScopeUsersRead, model.ScopeUsersWrite}) {
+		t.Errorf("unexpected create arguments %+v", keys)
+	}
+	if keys.expiresAt == nil || time.Until(*keys.expiresAt) < 719*time.Hour {
+		t.Errorf("expected the key to expire in 720h, got %v", keys.expiresAt)
+	}
+	if !strings.Contains(stdout, `"key": "`+cliRawKey+`"`) || !strings.Contains(stdout, "cannot be shown again") {
+		t.Errorf("expected the raw key once with a warning, got %s", stdout)
+	}
+
+	if code, _, stderr := runCommand(keys, "create", "-scopes", "users:read"); code != 2 || !strings.Contains(stderr, "invalid input: name is required") {
+		t.Errorf("expected a validation error, got %d %s", code, stderr)
+	}
+}
+
+func TestAPIKeysCommand_List(t *testing.T) {
+	code, stdout, _ := runCommand(&fakeAPIKeyService{}, "list")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("expected a header and one key, got %q", stdout)
+	}
+	for _, want := range []string{"billing", "crd_0123456789ab_", "users:read", "*", "active"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("expected %q in %q", want, lines[1])
+		}
+	}
+	if strings.Contains(stdout, "secret") {
+		t.Errorf("expected listed keys to be masked, got %s", stdout)
+	}
+}
+
+func TestAPIKeysCommand_Rotate(t *testing.T) {
+	keys := &fakeAPIKeyService{}
+	if code, stdout, _ := runCommand(keys, "rotate", "-id", "1"); code != 0 || !strings.Contains(stdout, cliRawKey) {
+		t.Errorf("expected the new raw key, got %d %s", code, stdout)
+	}
+	if keys.overlap != 24*time.Hour {
+		t.Errorf("expected the default overlap of 24h, got %v", keys.overlap)
+	}
+	if code, _, _ := runCommand(keys, "rotate", "-id", "1", "-overlap", "1h"); code != 0 || keys.overlap != time.Hour {
+		t.Errorf("expected a 1h overlap, got %d and %v", code, keys.overlap)
+	}
+	if code, _, stderr := runCommand(keys, "rotate", "-id", "9"); code != 1 || !strings.Contains(stderr, "api key not found") {
+		t.Errorf("expected an unknown key to be reported, got %d %s", code, stderr)
+	}
+	if code, _, _ := runCommand(keys, "rotate", "-overlap", "soon"); code != 2 {
+		t.Errorf("expected a flag error, got %d", code)
+	}
+}
+
+func TestAPIKeysCommand_Revoke(t *testing.T) {
+	if code, stdout, _ := runCommand(&fakeAPIKeyService{}, "revoke", "-id", "1"); code != 0 || stdout != "revoked api key 1\n" {
+		t.Errorf("expected the key to be revoked, got %d %q", code, stdout)
+	}
+	if code, _, stderr := runCommand(&fakeAPIKeyService{}, "revoke", "-id", "9"); code != 1 || !strings.Contains(stderr, "api key not found") {
+		t.Errorf("expected an unknown key to be reported, got %d %s", code, stderr)
+	}
+}
+
+func TestAPIKeysCommand_Usage(t *testing.T) {
+	if code, _, stderr := runCommand(&fakeAPIKeyService{}, "destroy"); code != 2 || !strings.Contains(stderr, "usage: cruder apikeys") {
+		t.Errorf("expected usage for an unknown command, got %d %s", code, stderr)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runAPIKeys(nil, &stdout, &stderr); code != 2 || !strings.Contains(stderr.String(), "usage: cruder apikeys") {
+		t.Errorf("expected usage without a command, got %d %s", code, stderr.String())
+	}
+}
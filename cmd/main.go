@@ -1,46 +1,490 @@
 package main
 
 import (
+	"context"
+	"cruder/internal/admin"
+	"cruder/internal/bruteforce"
+	"cruder/internal/certauth"
 	"cruder/internal/config"
 	"cruder/internal/controller"
 	"cruder/internal/handler"
+	"cruder/internal/health"
+	"cruder/internal/jwtauth"
+	"cruder/internal/logging"
+	"cruder/internal/metrics"
+	"cruder/internal/middleware"
+	"cruder/internal/ratelimit"
+	"cruder/internal/rbac"
 	"cruder/internal/repository"
+	"cruder/internal/server"
 	"cruder/internal/service"
+	"cruder/internal/signature"
+	"cruder/internal/tracing"
+	"cruder/internal/version"
+	"cruder/migrations"
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "apikeys" {
+		os.Exit(runAPIKeys(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	cfg, err := config.Load("config.yaml")
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
+	redactor, err := middleware.NewRedactor(middleware.RedactionOptions{
+		PathParams:  cfg.Logging.Redaction.PathParams,
+		QueryParams: cfg.Logging.Redaction.QueryParams,
+		Headers:     cfg.Logging.Redaction.Headers,
+		Patterns:    cfg.Logging.Redaction.Patterns,
+	})
+	if err != nil {
+		log.Fatalf("failed to set up log redaction: %v", err)
+	}
+
+	requestIDs, err := middleware.NewRequestIDGenerator(cfg.RequestID.Format)
+	if err != nil {
+		log.Fatalf("failed to set up request ids: %v", err)
+	}
+
+	logger, logOutput, err := logging.New(logging.Options{
+		Level:       cfg.Logging.Level,
+		Format:      cfg.Logging.Format,
+		Output:      cfg.Logging.Output,
+		ReplaceAttr: redactor.ReplaceAttr,
+	})
+	if err != nil {
+		log.Fatalf("failed to set up logging: %v", err)
+	}
+	defer logOutput.Close()
+	slog.SetDefault(logger)
+	for _, warning := range cfg.Warnings() {
+		logger.Warn("config warning", "warning", warning)
+	}
+
+	accessLogger, accessLogOutput, err := logging.NewAccess(logging.AccessOptions{
+		Options: logging.Options{
+			Level:       cfg.Logging.Level,
+			Format:      cfg.Logging.Format,
+			ReplaceAttr: redactor.ReplaceAttr,
+		},
+		Sinks:      cfg.Logging.Access.Sinks,
+		BufferSize: cfg.Logging.Access.BufferSize,
+		File: logging.FileOptions{
+			Path:       cfg.Logging.Access.File.Path,
+			MaxSizeMB:  cfg.Logging.Access.File.MaxSizeMB,
+			MaxAge:     cfg.Logging.Access.File.MaxAge,
+			MaxBackups: cfg.Logging.Access.File.MaxBackups,
+			Compress:   cfg.Logging.Access.File.Compress,
+		},
+		Syslog: logging.SyslogOptions{
+			Network: cfg.Logging.Access.Syslog.Network,
+			Address: cfg.Logging.Access.Syslog.Address,
+			Tag:     cfg.Logging.Access.Syslog.Tag,
+		},
+		OnDrop: metrics.AccessLogDropped,
+	})
+	if err != nil {
+		fatal(logger, "failed to set up access log", err)
+	}
+
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	dbConn, err := repository.NewPostgresConnection(cfg.GetDSN())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: cfg.Tracing.ServiceName,
+		Environment: cfg.Server.Env,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		Headers:     cfg.Tracing.Headers,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		fatal(logger, "failed to set up tracing", err)
+	}
+
+	pool := repository.PoolOptions{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
+	}
+
+	retry := repository.RetryOptions{
+		InitialInterval: cfg.Database.ConnectRetry.InitialInterval,
+		MaxInterval:     cfg.Database.ConnectRetry.MaxInterval,
+		MaxWait:         cfg.Database.ConnectRetry.MaxWait,
+		Multiplier:      cfg.Database.ConnectRetry.Multiplier,
+		Jitter:          cfg.Database.ConnectRetry.Jitter,
+	}
+
+	dbConn, err := repository.NewPostgresConnection(ctx, cfg.GetDSN(), logger, pool, retry, cfg.Database.ReplicaDSNs...)
+	if err != nil {
+		fatal(logger, "failed to connect to database", err)
+	}
+
+	logger.Info("database connected")
+
+	dbMonitor := repository.NewHealthMonitor(dbConn.DB(), logger, cfg.Database.HealthCheckInterval)
+
+	dbRouter := repository.NewDBRouter(dbConn.DB(), dbConn.Replicas(), repository.RouterOptions{
+		CheckInterval: cfg.Database.ReplicaCheckInterval,
+		StickyWindow:  cfg.Database.StickyWindow,
+	})
+
+	dbRouter.RegisterMetrics()
+
+	if len(cfg.Database.ReplicaDSNs) > 0 {
+		logger.Info("routing reads to replicas", "replicas", len(cfg.Database.ReplicaDSNs))
+	}
+
+	var migrationVersion int64
+	if cfg.Health.CheckMigrations {
+		migrationVersion, err = migrations.LatestVersion()
+		if err != nil {
+			fatal(logger, "failed to read migrations", err)
+		}
+	}
+
+	repositories := repository.NewRepository(dbRouter, logger, repository.Options{
+		SQLComments:        cfg.RequestID.SQLComments,
+		SlowQueryThreshold: cfg.Database.SlowQueryThreshold,
+		ExplainSlowQueries: cfg.Database.ExplainSlowQueries && cfg.Server.Env != "production",
+		RowLevelSecurity:   cfg.Tenancy.RowLevelSecurity,
+	})
+	services := service.NewService(repositories, migrationVersion, logger, service.APIKeyOptions{
+		StaticKey:        cfg.API.Key,
+		DatabaseKeys:     cfg.API.Keys.Enabled,
+		CacheTTL:         cfg.API.Keys.CacheTTL,
+		LastUsedInterval: cfg.API.Keys.LastUsedInterval,
+	})
+
+	build := version.Get()
+	buildAttrs := []any{
+		"version", build.Version,
+		"commit", build.Commit,
+		"build_date", build.BuildDate,
+		"go_version", build.GoVersion,
+		"os", build.OS,
+		"arch", build.Arch,
+	}
+	if status, err := services.Database.Migrations(ctx); err != nil {
+		buildAttrs = append(buildAttrs, "migration_error", err)
+	} else {
+		buildAttrs = append(buildAttrs, "migration_version", status.Applied)
 	}
-	defer dbConn.Close()
+	logger.Info("build info", buildAttrs...)
 
-	log.Println("Database connected successfully")
+	registry := health.NewRegistry(cfg.Health.Timeout)
+	registry.AddReadiness(
+		health.NewChecker("database", services.Database.Ping),
+		health.NewChecker("migrations", services.Database.CheckMigrations),
+	)
+	registry.AddStartup(
+		health.NewChecker("database", services.Database.Ping),
+		health.NewChecker("migrations", services.Database.CheckMigrations),
+	)
 
-	repositories := repository.NewRepository(dbConn.DB())
-	services := service.NewService(repositories)
-	controllers := controller.NewController(services)
+	controllers := controller.NewController(services, registry, logger)
 
-	r := gin.Default()
+	var auth middleware.AuthOptions
+	var guard *bruteforce.Guard
+	if cfg.API.Key != "" || cfg.API.Keys.Enabled {
+		auth.APIKeys = services.APIKeys
+		if cfg.API.BruteForce.Enabled {
+			guard = bruteforce.NewGuard(logger, bruteforce.Options{
+				Window:      cfg.API.BruteForce.Window,
+				DelayAfter:  cfg.API.BruteForce.DelayAfter,
+				BaseDelay:   cfg.API.BruteForce.BaseDelay,
+				MaxDelay:    cfg.API.BruteForce.MaxDelay,
+				BanAfter:    cfg.API.BruteForce.BanAfter,
+				BanDuration: cfg.API.BruteForce.BanDuration,
+			})
+			auth.Guard = guard
+		}
+	}
+	if tlsCfg := cfg.Server.TLS; tlsCfg.Enabled && tlsCfg.ClientAuth != "none" {
+		identities := make([]certauth.Identity, 0, len(tlsCfg.ClientIdentities))
+		for _, id := range tlsCfg.ClientIdentities {
+			identities = append(identities, certauth.Identity{Subject: id.Subject, Scopes: id.Scopes, Roles: id.Roles, Tenant: id.Tenant})
+		}
+		auth.ClientCerts = certauth.NewAuthenticator(identities)
+	}
+	if signingCfg := cfg.API.Signing; signingCfg.Enabled {
+		keys := make([]signature.Key, 0, len(signingCfg.Keys))
+		for _, k := range signingCfg.Keys {
+			keys = append(keys, signature.Key{ID: k.ID, Secret: []byte(k.Secret), Scopes: k.Scopes, Roles: k.Roles, Tenant: k.Tenant})
+		}
+		var nonces signature.NonceCache = signature.NewMemoryNonceCache()
+		if signingCfg.NonceStore == "redis" {
+			nonceClient := redis.NewClient(&redis.Options{
+				Addr:     signingCfg.Redis.Address,
+				Username: signingCfg.Redis.Username,
+				Password: signingCfg.Redis.Password,
+				DB:       signingCfg.Redis.DB,
+			})
+			defer nonceClient.Close()
+			if err := nonceClient.Ping(ctx).Err(); err != nil {
+				logger.Warn("nonce store unreachable, signed requests are refused until it recovers", "error", err)
+			}
+			nonces = signature.NewRedisNonceCache(nonceClient, signingCfg.Redis.KeyPrefix)
+		}
+		maxBody := cfg.Server.BodyLimit.MaxBytes
+		for _, limit := range cfg.Server.BodyLimit.Routes {
+			maxBody = max(maxBody, limit)
+		}
+		auth.Signatures = signature.NewVerifier(keys, nonces, signature.Options{MaxSkew: signingCfg.MaxSkew, MaxBodyBytes: maxBody})
+	}
+	if cfg.API.JWT.Enabled {
+		keySet, err := jwtauth.NewKeySet(ctx, jwtauth.KeySetOptions{
+			URL:             cfg.API.JWT.JWKSURL,
+			File:            cfg.API.JWT.JWKSFile,
+			RefreshInterval: cfg.API.JWT.RefreshInterval,
+			Logger:          logger,
+		})
+		if err != nil {
+			fatal(logger, "failed to load jwks", err)
+		}
+		defer keySet.Close()
+
+		mappings := make([]jwtauth.ClaimMapping, 0, len(cfg.API.JWT.ClaimMappings))
+		for _, m := range cfg.API.JWT.ClaimMappings {
+			mappings = append(mappings, jwtauth.ClaimMapping{Claim: m.Claim, Value: m.Value, Scopes: m.Scopes, Roles: m.Roles})
+		}
+		auth.Tokens = jwtauth.NewVerifier(keySet, jwtauth.VerifierOptions{
+			Issuer:        cfg.API.JWT.Issuer,
+			Audience:      cfg.API.JWT.Audience,
+			Leeway:        cfg.API.JWT.Leeway,
+			ScopeClaim:    cfg.API.JWT.ScopeClaim,
+			RoleClaim:     cfg.API.JWT.RoleClaim,
+			TenantClaim:   cfg.Tenancy.Claim,
+			ClaimMappings: mappings,
+		})
+	}
+
+	var authorizer middleware.Authorizer
+	if cfg.API.RBAC.Enabled {
+		policy, err := rbac.Load(cfg.API.RBAC.PolicyFile)
+		if err != nil {
+			fatal(logger, "failed to load access policy", err)
+		}
+		authorizer = rbac.NewEnforcer(policy, logger)
+	}
+
+	var cors *middleware.CORSOptions
+	if c := cfg.Server.CORS; c.Enabled {
+		cors = &middleware.CORSOptions{
+			AllowedOrigins:   c.AllowedOrigins,
+			AllowedMethods:   c.AllowedMethods,
+			AllowedHeaders:   c.AllowedHeaders,
+			ExposedHeaders:   c.ExposedHeaders,
+			AllowCredentials: c.AllowCredentials,
+			MaxAge:           c.MaxAge,
+		}
+	}
+	var securityHeaders *middleware.SecurityHeadersOptions
+	if h := cfg.Server.SecurityHeaders; h.Enabled {
+		securityHeaders = &middleware.SecurityHeadersOptions{
+			HSTSMaxAge:            h.HSTSMaxAge,
+			HSTSIncludeSubdomains: h.HSTSIncludeSubdomains,
+			ContentSecurityPolicy: h.ContentSecurityPolicy,
+			FrameOptions:          h.FrameOptions,
+			ReferrerPolicy:        h.ReferrerPolicy,
+		}
+	}
+
+	var rateLimit middleware.RateLimitOptions
+	if cfg.RateLimit.Enabled {
+		rateLimit = middleware.RateLimitOptions{
+			Store:     ratelimit.NewMemoryStore(),
+			PerIP:     ratelimit.Limit(cfg.RateLimit.PerIP),
+			PerClient: ratelimit.Limit(cfg.RateLimit.PerClient),
+			Routes:    make(map[string]ratelimit.Limit, len(cfg.RateLimit.Routes)),
+		}
+		for route, limit := range cfg.RateLimit.Routes {
+			rateLimit.Routes[route] = ratelimit.Limit(limit)
+		}
+		if cfg.RateLimit.Store == "redis" {
+			redisClient := redis.NewClient(&redis.Options{
+				Addr:     cfg.RateLimit.Redis.Address,
+				Username: cfg.RateLimit.Redis.Username,
+				Password: cfg.RateLimit.Redis.Password,
+				DB:       cfg.RateLimit.Redis.DB,
+			})
+			defer redisClient.Close()
+			if err := redisClient.Ping(ctx).Err(); err != nil {
+				logger.Warn("rate limit store unreachable, requests are let through until it recovers", "error", err)
+			}
+			rateLimit.Store = ratelimit.NewRedisStore(redisClient, cfg.RateLimit.Redis.KeyPrefix)
+		}
+	}
+
+	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		fatal(logger, "invalid trusted proxies", err)
+	}
+	r.Use(middleware.RecoveryMiddleware(logger))
 
-	handler.New(r, controllers.Users, cfg.API.Key)
+	handler.New(r, controllers, handler.Options{
+		AccessLogger: accessLogger,
+		AccessLog: middleware.AccessLogOptions{
+			Redactor:         redactor,
+			SampleRate:       cfg.Logging.Access.SampleRate,
+			RouteSampleRates: cfg.Logging.Access.RouteSampleRates,
+			SlowThreshold:    cfg.Logging.Access.SlowThreshold,
+		},
+		RequestID: middleware.RequestIDOptions{
+			Generator:       requestIDs,
+			MaxLength:       cfg.RequestID.MaxLength,
+			TrustIncoming:   cfg.RequestID.TrustIncoming,
+			FromTraceparent: cfg.RequestID.FromTraceparent,
+		},
+		CORS:            cors,
+		SecurityHeaders: securityHeaders,
+		BodyLimit: middleware.BodyLimitOptions{
+			MaxBytes: cfg.Server.BodyLimit.MaxBytes,
+			Routes:   cfg.Server.BodyLimit.Routes,
+		},
+		Auth:       auth,
+		Authorizer: authorizer,
+		Tenants:    services.Tenants,
+		Tenant: middleware.TenantOptions{
+			Header:        cfg.Tenancy.Header,
+			DefaultTenant: cfg.Tenancy.DefaultTenant,
+		},
+		RateLimit:         rateLimit,
+		SessionHeader:     cfg.Database.SessionHeader,
+		DatabaseAvailable: dbMonitor.Healthy,
+	})
+
+	serverOpts := server.Options{
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ShutdownDelay:     cfg.Server.ShutdownDelay,
+		ShutdownTimeout:   cfg.Server.ShutdownTimeout,
+	}
+
+	// The admin and debug listeners carry bearer tokens, so they are served
+	// with the same certificates as the API whenever TLS is on. Client
+	// certificates stay optional there so metrics scrapers and probes can
+	// connect without one.
+	apiOpts := serverOpts
+	managementOpts := serverOpts
+	if tlsCfg := cfg.Server.TLS; tlsCfg.Enabled {
+		reloader, err := server.NewTLSReloader(server.TLSOptions{
+			CertFile:       tlsCfg.CertFile,
+			KeyFile:        tlsCfg.KeyFile,
+			MinVersion:     tlsCfg.MinVersion,
+			CipherSuites:   tlsCfg.CipherSuites,
+			ClientAuth:     tlsCfg.ClientAuth,
+			ClientCAFile:   tlsCfg.ClientCAFile,
+			ReloadInterval: tlsCfg.ReloadInterval,
+			Logger:         logger,
+		})
+		if err != nil {
+			fatal(logger, "failed to load tls files", err)
+		}
+		go reloader.Run(ctx)
+		apiOpts.TLS = reloader.Config()
+		managementOpts.TLS = reloader.ManagementConfig()
+	}
+
+	adminDone := make(chan error, 1)
+	if cfg.Server.Admin.Enabled {
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Server.Admin.Host, cfg.Server.Admin.Port)
+		adminOpts := admin.Options{
+			QueryStats: repositories.QueryStats,
+			APIKeys:    services.APIKeys,
+			Tenants:    services.Tenants,
+			Token:      cfg.Server.Admin.Token,
+		}
+		if guard != nil {
+			adminOpts.Bans = guard
+		}
+		adminSrv := server.New(adminAddr, admin.NewHandler(adminOpts), managementOpts)
+		logger.Info("starting admin server", "addr", adminAddr, "tls", cfg.Server.TLS.Enabled)
+		go func() {
+			adminDone <- adminSrv.Run(ctx, nil)
+		}()
+	} else {
+		adminDone <- nil
+	}
+
+	debugDone := make(chan error, 1)
+	if cfg.Server.Debug.Enabled {
+		debugAddr := fmt.Sprintf("%s:%d", cfg.Server.Debug.Host, cfg.Server.Debug.Port)
+		debugSrv := server.New(debugAddr, admin.NewDebugHandler(admin.DebugOptions{
+			Token:  cfg.Server.Debug.Token,
+			Config: cfg.Sanitized(),
+		}), managementOpts)
+		logger.Info("starting debug server", "addr", debugAddr, "tls", cfg.Server.TLS.Enabled)
+		go func() {
+			debugDone <- debugSrv.Run(ctx, nil)
+		}()
+	} else {
+		debugDone <- nil
+	}
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Starting server on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatalf("failed to run server: %v", err)
+	srv := server.New(addr, r, apiOpts)
+
+	logger.Info("starting server", "addr", addr, "tls", cfg.Server.TLS.Enabled)
+	runErr := srv.Run(ctx, func() {
+		stop()
+		registry.SetShuttingDown()
+	})
+	if runErr != nil {
+		logger.Error("server error", "error", runErr)
+	}
+	if err := <-adminDone; err != nil {
+		logger.Error("admin server error", "error", err)
+	}
+	if err := <-debugDone; err != nil {
+		logger.Error("debug server error", "error", err)
+	}
+
+	if err := accessLogOutput.Close(); err != nil {
+		logger.Error("failed to flush access log", "error", err)
 	}
+
+	dbMonitor.Close()
+	dbRouter.Close()
+	if err := dbConn.Close(); err != nil {
+		logger.Error("failed to close database", "error", err)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+	cancel()
+	logger.Info("shutdown complete")
+
+	if runErr != nil {
+		os.Exit(1)
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"net/http"
+
+	"cruder/internal/metrics"
+	"cruder/internal/model"
+)
+
+type QueryStats interface {
+	Snapshot() []model.QueryStats
+	Reset()
+}
+
+type Options struct {
+	QueryStats QueryStats
+	// APIKeys, Tenants and Bans enable key, tenant and ban management; the
+	// routes, and those of QueryStats, are only registered when Token is
+	// set, since they can mint credentials or expose statements.
+	APIKeys APIKeyManager
+	Tenants TenantManager
+	Bans    BanManager
+	Token   string
+}
+
+// NewHandler builds the admin listener's routes. It is served on its own
+// port so operational endpoints never pass through the public API's auth.
+func NewHandler(opts Options) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	// Query statistics carry statement text, so they are only served with
+	// the token, like the management routes.
+	if opts.QueryStats != nil && opts.Token != "" {
+		mux.Handle("GET /queries", requireToken(opts.Token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{"statements": opts.QueryStats.Snapshot()})
+		})))
+		mux.Handle("DELETE /queries", requireToken(opts.Token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			opts.QueryStats.Reset()
+			w.WriteHeader(http.StatusNoContent)
+		})))
+	}
+	if opts.APIKeys != nil && opts.Token != "" {
+		registerAPIKeys(mux, opts.APIKeys, opts.Token)
+	}
+	if opts.Tenants != nil && opts.Token != "" {
+		registerTenants(mux, opts.Tenants, opts.Token)
+	}
+	if opts.Bans != nil && opts.Token != "" {
+		registerBans(mux, opts.Bans, opts.Token)
+	}
+	return mux
+}
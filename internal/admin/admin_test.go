@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cruder/internal/model"
+)
+
+const testToken = "admin-token"
+
+type fakeQueryStats struct{ resets int }
+
+func (s *fakeQueryStats) Snapshot() []model.QueryStats {
+	return []model.QueryStats{{Statement: "SELECT 1", Calls: 1}}
+}
+
+func (s *fakeQueryStats) Reset() { s.resets++ }
+
+func adminRequest(h http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	return adminRequestBody(h, method, target, token, "")
+}
+
+func adminRequestBody(h http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestQueries_RequireToken(t *testing.T) {
+	stats := &fakeQueryStats{}
+	h := NewHandler(Options{QueryStats: stats, Token: testToken})
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if w := adminRequest(h, method, "/queries", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s without a token: expected 401, got %d", method, w.Code)
+		}
+		if w := adminRequest(h, method, "/queries", "wrong"); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s with a wrong token: expected 401, got %d", method, w.Code)
+		}
+	}
+	if stats.resets != 0 {
+		t.Fatal("expected unauthenticated resets to be refused")
+	}
+
+	if w := adminRequest(h, http.MethodGet, "/queries", testToken); w.Code != http.StatusOK {
+		t.Errorf("expected 200 with the token, got %d", w.Code)
+	}
+	if w := adminRequest(h, http.MethodDelete, "/queries", testToken); w.Code != http.StatusNoContent || stats.resets != 1 {
+		t.Errorf("expected the reset with the token, got %d", w.Code)
+	}
+
+	open := NewHandler(Options{QueryStats: stats})
+	if w := adminRequest(open, http.MethodGet, "/queries", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected the routes to be off without a token, got %d", w.Code)
+	}
+	if w := adminRequest(open, http.MethodGet, "/metrics", ""); w.Code != http.StatusOK {
+		t.Errorf("expected metrics to stay open, got %d", w.Code)
+	}
+}
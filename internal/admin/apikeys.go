@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cruder/internal/audit"
+	"cruder/internal/model"
+)
+
+type APIKeyManager interface {
+	List(ctx context.Context) ([]model.APIKey, error)
+	Create(ctx context.Context, name, tenant string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error)
+	Rotate(ctx context.Context, id int, overlap time.Duration) (string, *model.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Tenant    string     `json:"tenant"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type rotateAPIKeyRequest struct {
+	Overlap string `json:"overlap"`
+}
+
+// createdAPIKeyResponse is the only place the raw key is ever returned.
+type createdAPIKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey *model.APIKey `json:"api_key"`
+}
+
+func registerAPIKeys(mux *http.ServeMux, keys APIKeyManager, token string) {
+	handle := func(pattern string, fn http.HandlerFunc) {
+		mux.Handle(pattern, requireToken(token, withActor(fn)))
+	}
+
+	handle("GET /api-keys", func(w http.ResponseWriter, r *http.Request) {
+		list, err := keys.List(r.Context())
+		if err != nil {
+			writeError(w, err, "api key")
+			return
+		}
+		writeJSON(w, map[string]any{"api_keys": list})
+	})
+
+	handle("POST /api-keys", func(w http.ResponseWriter, r *http.Request) {
+		var req createAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+		rawKey, key, err := keys.Create(r.Context(), req.Name, req.Tenant, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			writeError(w, err, "api key")
+			return
+		}
+		writeJSONStatus(w, http.StatusCreated, createdAPIKeyResponse{Key: rawKey, APIKey: key})
+	})
+
+	handle("POST /api-keys/{id}/rotate", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+			return
+		}
+		var req rotateAPIKeyRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+				return
+			}
+		}
+		overlap := 24 * time.Hour
+		if req.Overlap != "" {
+			if overlap, err = time.ParseDuration(req.Overlap); err != nil {
+				writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid overlap"})
+				return
+			}
+		}
+		rawKey, key, err := keys.Rotate(r.Context(), id, overlap)
+		if err != nil {
+			writeError(w, err, "api key")
+			return
+		}
+		writeJSONStatus(w, http.StatusCreated, createdAPIKeyResponse{Key: rawKey, APIKey: key})
+	})
+
+	handle("DELETE /api-keys/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+			return
+		}
+		if err := keys.Revoke(r.Context(), id); err != nil {
+			writeError(w, err, "api key")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// withActor attributes audit events to the admin caller's address.
+func withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := audit.WithActor(r.Context(), "admin:"+r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeError maps service errors to responses; resource names what was not
+// found.
+func writeError(w http.ResponseWriter, err error, resource string) {
+	var validationErr *model.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": validationErr.Message})
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSONStatus(w, http.StatusNotFound, map[string]string{"error": resource + " not found"})
+	default:
+		writeJSONStatus(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+	}
+}
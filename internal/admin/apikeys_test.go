@@ -0,0 +1,167 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+)
+
+const testRawKey = "crd_0123456789ab_secret"
+
+type fakeAPIKeys struct {
+	overlap time.Duration
+	revoked []int
+}
+
+func (f *fakeAPIKeys) List(ctx context.Context) ([]model.APIKey, error) {
+	return []model.APIKey{{ID: 1, Name: "billing", Prefix: "0123456789ab", KeyHash: "hash", Scopes: []string{model.ScopeUsersRead}}}, nil
+}
+
+func (f *fakeAPIKeys) Create(ctx context.Context, name, tenant string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error) {
+	if name == "" {
+		return "", nil, model.NewRuleValidationError("api_key_name_required", "name is required")
+	}
+	return testRawKey, &model.APIKey{ID: 2, Name: name, Prefix: "0123456789ab", KeyHash: "hash", Scopes: scopes, Tenant: tenant}, nil
+}
+
+func (f *fakeAPIKeys) Rotate(ctx context.Context, id int, overlap time.Duration) (string, *model.APIKey, error) {
+	if id != 1 {
+		return "", nil, sql.ErrNoRows
+	}
+	f.overlap = overlap
+	return testRawKey, &model.APIKey{ID: 2, Name: "billing", Prefix: "0123456789ab", KeyHash: "hash", RotatedFrom: &id}, nil
+}
+
+func (f *fakeAPIKeys) Revoke(ctx context.Context, id int) error {
+	if id != 1 {
+		return sql.ErrNoRows
+	}
+	f.revoked = append(f.revoked, id)
+	return nil
+}
+
+func TestAPIKeys_RequireToken(t *testing.T) {
+	keys := &fakeAPIKeys{}
+	h := NewHandler(Options{APIKeys: keys, Token: testToken})
+
+	for _, route := range []struct{ method, target string }{
+		{http.MethodGet, "/api-keys"},
+		{http.MethodPost, "/api-keys"},
+		{http.MethodPost, "/api-keys/1/rotate"},
+		{http.MethodDelete, "/api-keys/1"},
+	} {
+		if w := adminRequest(h, route.method, route.target, "wrong"); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s %s: expected 401 with a challenge, got %d", route.method, route.target, w.Code)
+		}
+	}
+	if len(keys.revoked) != 0 {
+		t.Error("expected unauthenticated revocations to be refused")
+	}
+
+	if w := adminRequest(NewHandler(Options{APIKeys: keys}), http.MethodGet, "/api-keys", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected the routes to be off without a token, got %d", w.Code)
+	}
+}
+
+func TestAPIKeys_Create(t *testing.T) {
+	h := NewHandler(Options{APIKeys: &fakeAPIKeys{}, Token: testToken})
+
+	w := adminRequestBody(h, http.MethodPost, "/api-keys", testToken, `{"name":"reporting","scopes":["users:read"],"tenant":"acme"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Key    string `json:"key"`
+		APIKey struct {
+			ID     int    `json:"id"`
+			Tenant string `json:"tenant"`
+			Key    string `json:"key"`
+		} `json:"api_key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Key != testRawKey || created.APIKey.ID != 2 || created.APIKey.Tenant != "acme" {
+		t.Errorf("unexpected response %s", w.Body.String())
+	}
+	if created.APIKey.Key == testRawKey || strings.Contains(w.Body.String(), `"hash"`) {
+		t.Errorf("expected the key record to be masked, got %s", w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{body: `{"name":`, status: http.StatusBadRequest},
+		{body: `{"scopes":["users:read"]}`, status: http.StatusBadRequest},
+	} {
+		if w := adminRequestBody(h, http.MethodPost, "/api-keys", testToken, tt.body); w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.body, tt.status, w.Code)
+		}
+	}
+}
+
+func TestAPIKeys_List(t *testing.T) {
+	h := NewHandler(Options{APIKeys: &fakeAPIKeys{}, Token: testToken})
+
+	w := adminRequest(h, http.MethodGet, "/api-keys", testToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"name": "billing"`) || strings.Contains(body, testRawKey) || strings.Contains(body, `"hash"`) {
+		t.Errorf("expected listed keys without the raw key or hash, got %s", body)
+	}
+}
+
+func TestAPIKeys_Rotate(t *testing.T) {
+	keys := &fakeAPIKeys{}
+	h := NewHandler(Options{APIKeys: keys, Token: testToken})
+
+	w := adminRequest(h, http.MethodPost, "/api-keys/1/rotate", testToken)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"key": "`+testRawKey+`"`) {
+		t.Fatalf("expected the new raw key, got %d %s", w.Code, w.Body.String())
+	}
+	if keys.overlap != 24*time.Hour {
+		t.Errorf("expected the default overlap of 24h, got %v", keys.overlap)
+	}
+
+	if w := adminRequestBody(h, http.MethodPost, "/api-keys/1/rotate", testToken, `{"overlap":"1h"}`); w.Code != http.StatusCreated || keys.overlap != time.Hour {
+		t.Errorf("expected a 1h overlap, got %d and %v", w.Code, keys.overlap)
+	}
+
+	for _, tt := range []struct {
+		target, body string
+		status       int
+		message      string
+	}{
+		{target: "/api-keys/x/rotate", status: http.StatusBadRequest, message: "invalid id"},
+		{target: "/api-keys/1/rotate", body: `{"overlap":"soon"}`, status: http.StatusBadRequest, message: "invalid overlap"},
+		{target: "/api-keys/9/rotate", status: http.StatusNotFound, message: "api key not found"},
+	} {
+		w := adminRequestBody(h, http.MethodPost, tt.target, testToken, tt.body)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.message) {
+			t.Errorf("%s %s: expected %d %q, got %d %s", tt.target, tt.body, tt.status, tt.message, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAPIKeys_Revoke(t *testing.T) {
+	keys := &fakeAPIKeys{}
+	h := NewHandler(Options{APIKeys: keys, Token: testToken})
+
+	if w := adminRequest(h, http.MethodDelete, "/api-keys/1", testToken); w.Code != http.StatusNoContent || len(keys.revoked) != 1 {
+		t.Errorf("expected the key to be revoked, got %d", w.Code)
+	}
+	if w := adminRequest(h, http.MethodDelete, "/api-keys/9", testToken); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown key, got %d", w.Code)
+	}
+	if w := adminRequest(h, http.MethodDelete, "/api-keys/x", testToken); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid id, got %d", w.Code)
+	}
+}
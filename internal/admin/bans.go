@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"cruder/internal/model"
+)
+
+type BanManager interface {
+	Bans() []model.Ban
+	Clear(ctx context.Context, kind, value string) bool
+	ClearAll(ctx context.Context) int
+}
+
+func registerBans(mux *http.ServeMux, bans BanManager, token string) {
+	handle := func(pattern string, fn http.HandlerFunc) {
+		mux.Handle(pattern, requireToken(token, withActor(fn)))
+	}
+
+	handle("GET /bans", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"bans": bans.Bans()})
+	})
+
+	handle("DELETE /bans", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"cleared": bans.ClearAll(r.Context())})
+	})
+
+	handle("DELETE /bans/{kind}/{value}", func(w http.ResponseWriter, r *http.Request) {
+		if !bans.Clear(r.Context(), r.PathValue("kind"), r.PathValue("value")) {
+			writeJSONStatus(w, http.StatusNotFound, map[string]string{"error": "ban not found"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
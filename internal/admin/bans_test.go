@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+)
+
+type fakeBans struct {
+	bans []model.Ban
+}
+
+func (f *fakeBans) Bans() []model.Ban {
+	return f.bans
+}
+
+func (f *fakeBans) Clear(ctx context.Context, kind, value string) bool {
+	for i, ban := range f.bans {
+		if ban.Kind == kind && ban.Value == value {
+			f.bans = append(f.bans[:i], f.bans[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeBans) ClearAll(ctx context.Context) int {
+	n := len(f.bans)
+	f.bans = nil
+	return n
+}
+
+func TestBans(t *testing.T) {
+	until := time.Now().Add(time.Minute)
+	bans := &fakeBans{bans: []model.Ban{
+		{Kind: "ip", Value: "203.0.113.1", Failures: 10, Until: until},
+		{Kind: "prefix", Value: "0123456789ab", Failures: 20, Until: until},
+		{Kind: "ip", Value: "203.0.113.2", Failures: 10, Until: until},
+	}}
+	h := NewHandler(Options{Bans: bans, Token: testToken})
+
+	if w := adminRequest(h, http.MethodDelete, "/bans", ""); w.Code != http.StatusUnauthorized || len(bans.bans) != 3 {
+		t.Errorf("expected 401 without the token, got %d", w.Code)
+	}
+
+	if w := adminRequest(h, http.MethodGet, "/bans", testToken); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"value": "0123456789ab"`) {
+		t.Errorf("expected the ban list, got %d %s", w.Code, w.Body.String())
+	}
+	if w := adminRequest(h, http.MethodDelete, "/bans/ip/203.0.113.1", testToken); w.Code != http.StatusNoContent || len(bans.bans) != 2 {
+		t.Errorf("expected the ban to be cleared, got %d", w.Code)
+	}
+	if w := adminRequest(h, http.MethodDelete, "/bans/ip/203.0.113.1", testToken); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "ban not found") {
+		t.Errorf("expected 404 for a cleared ban, got %d %s", w.Code, w.Body.String())
+	}
+	if w := adminRequest(h, http.MethodDelete, "/bans", testToken); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cleared": 2`) {
+		t.Errorf("expected every ban to be cleared, got %d %s", w.Code, w.Body.String())
+	}
+}
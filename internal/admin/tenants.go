@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"cruder/internal/model"
+)
+
+type TenantManager interface {
+	List(ctx context.Context) ([]model.Tenant, error)
+	Create(ctx context.Context, req *model.CreateTenantRequest) (*model.Tenant, error)
+}
+
+func registerTenants(mux *http.ServeMux, tenants TenantManager, token string) {
+	handle := func(pattern string, fn http.HandlerFunc) {
+		mux.Handle(pattern, requireToken(token, withActor(fn)))
+	}
+
+	handle("GET /tenants", func(w http.ResponseWriter, r *http.Request) {
+		list, err := tenants.List(r.Context())
+		if err != nil {
+			writeError(w, err, "tenant")
+			return
+		}
+		writeJSON(w, map[string]any{"tenants": list})
+	})
+
+	handle("POST /tenants", func(w http.ResponseWriter, r *http.Request) {
+		var req model.CreateTenantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONStatus(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+			return
+		}
+		tenant, err := tenants.Create(r.Context(), &req)
+		if err != nil {
+			writeError(w, err, "tenant")
+			return
+		}
+		writeJSONStatus(w, http.StatusCreated, tenant)
+	})
+}
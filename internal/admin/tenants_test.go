@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"cruder/internal/model"
+)
+
+type fakeTenants struct{ created []string }
+
+func (f *fakeTenants) List(ctx context.Context) ([]model.Tenant, error) {
+	return []model.Tenant{{ID: 1, Slug: "default", Name: "Default"}}, nil
+}
+
+func (f *fakeTenants) Create(ctx context.Context, req *model.CreateTenantRequest) (*model.Tenant, error) {
+	if req.Slug == "default" {
+		return nil, model.NewRuleValidationError("tenant_slug_taken", "tenant slug is already taken")
+	}
+	f.created = append(f.created, req.Slug)
+	return &model.Tenant{ID: 2, Slug: req.Slug, Name: req.Name}, nil
+}
+
+func TestTenants(t *testing.T) {
+	tenants := &fakeTenants{}
+	h := NewHandler(Options{Tenants: tenants, Token: testToken})
+
+	if w := adminRequestBody(h, http.MethodPost, "/tenants", "", `{"slug":"acme","name":"Acme"}`); w.Code != http.StatusUnauthorized || len(tenants.created) != 0 {
+		t.Errorf("expected 401 without the token, got %d", w.Code)
+	}
+
+	if w := adminRequest(h, http.MethodGet, "/tenants", testToken); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"slug": "default"`) {
+		t.Errorf("expected the tenant list, got %d %s", w.Code, w.Body.String())
+	}
+	if w := adminRequestBody(h, http.MethodPost, "/tenants", testToken, `{"slug":"acme","name":"Acme"}`); w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"slug": "acme"`) {
+		t.Errorf("expected the tenant to be created, got %d %s", w.Code, w.Body.String())
+	}
+	if w := adminRequestBody(h, http.MethodPost, "/tenants", testToken, `{"slug":"default","name":"Again"}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "already taken") {
+		t.Errorf("expected 400 for a taken slug, got %d %s", w.Code, w.Body.String())
+	}
+	if w := adminRequestBody(h, http.MethodPost, "/tenants", testToken, `not json`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed body, got %d", w.Code)
+	}
+}
@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+type actorKey struct{}
+
+// WithActor records who is performing the operations made with ctx.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "unknown"
+}
+
+// Logger writes audit events as structured log records tagged with
+// log.type=audit, so they can be routed and retained separately.
+type Logger struct {
+	logger *slog.Logger
+}
+
+func New(logger *slog.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+func (l *Logger) Record(ctx context.Context, action, outcome string, attrs ...any) {
+	level := slog.LevelInfo
+	if outcome != OutcomeSuccess {
+		level = slog.LevelWarn
+	}
+	attrs = append([]any{
+		"log.type", "audit",
+		"audit.action", action,
+		"audit.actor", Actor(ctx),
+		"audit.outcome", outcome,
+	}, attrs...)
+	l.logger.Log(ctx, level, "audit "+action, attrs...)
+}
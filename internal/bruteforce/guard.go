@@ -0,0 +1,257 @@
+package bruteforce
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"cruder/internal/metrics"
+	"cruder/internal/model"
+)
+
+const sweepInterval = time.Minute
+
+type Options struct {
+	// Window is how long a failure counts against a client.
+	Window time.Duration
+	// DelayAfter failures within the window, each further failure is
+	// answered after BaseDelay, doubling up to MaxDelay.
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	// BanAfter failures within the window ban the client for BanDuration.
+	BanAfter    int
+	BanDuration time.Duration
+	// MaxRecords bounds the clients tracked at once. When it is reached,
+	// the oldest client that is not banned is forgotten.
+	MaxRecords int
+}
+
+type record struct {
+	failures    int
+	first       time.Time
+	bannedUntil time.Time
+}
+
+// Guard tracks failed authentications per client address and per API key
+// prefix. State is kept per replica, as is ban management through the
+// admin listener.
+type Guard struct {
+	opts   Options
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	records   map[[2]string]*record
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewGuard(logger *slog.Logger, opts Options) *Guard {
+	if opts.Window <= 0 {
+		opts.Window = 15 * time.Minute
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 250 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 5 * time.Second
+	}
+	if opts.BanDuration <= 0 {
+		opts.BanDuration = 15 * time.Minute
+	}
+	if opts.MaxRecords <= 0 {
+		opts.MaxRecords = 10_000
+	}
+	return &Guard{
+		opts:      opts,
+		logger:    logger,
+		records:   make(map[[2]string]*record),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Check reports whether the client address or key prefix is banned and for
+// how long. Empty values are not checked.
+func (g *Guard) Check(ip, prefix string) (time.Duration, bool) {
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var retryAfter time.Duration
+	for _, key := range keys(ip, prefix) {
+		if r, ok := g.records[key]; ok && now.Before(r.bannedUntil) {
+			retryAfter = max(retryAfter, r.bannedUntil.Sub(now))
+		}
+	}
+	return retryAfter, retryAfter > 0
+}
+
+// Failure records a failed authentication and returns how long to delay the
+// response.
+func (g *Guard) Failure(ctx context.Context, ip, prefix string) time.Duration {
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Sub(g.lastSweep) >= sweepInterval {
+		g.sweep(now)
+	}
+
+	var delay time.Duration
+	for _, key := range keys(ip, prefix) {
+		r, ok := g.records[key]
+		if !ok && len(g.records) >= g.opts.MaxRecords && !g.evict(now) {
+			// Every tracked client is banned; keep the bans rather than
+			// make room for a new one.
+			continue
+		}
+		if !ok || (now.Sub(r.first) > g.opts.Window && !now.Before(r.bannedUntil)) {
+			r = &record{first: now}
+			g.records[key] = r
+		}
+		r.failures++
+
+		if g.opts.BanAfter > 0 && r.failures >= g.opts.BanAfter && !now.Before(r.bannedUntil) {
+			r.bannedUntil = now.Add(g.opts.BanDuration)
+			metrics.AuthBan(key[0])
+			g.logger.WarnContext(ctx, "security event",
+				"log.type", "security",
+				"event", "auth.ban",
+				"ban.kind", key[0],
+				"ban.value", key[1],
+				"failures", r.failures,
+				"ban.until", r.bannedUntil,
+			)
+		}
+		if g.opts.DelayAfter > 0 && r.failures > g.opts.DelayAfter {
+			delay = max(delay, g.delay(r.failures-g.opts.DelayAfter))
+		}
+	}
+
+	g.logger.InfoContext(ctx, "security event",
+		"log.type", "security",
+		"event", "auth.failure",
+		"client.address", ip,
+		"api_key.prefix", prefix,
+		"delay", delay.String(),
+	)
+	return delay
+}
+
+func (g *Guard) delay(excess int) time.Duration {
+	delay := g.opts.BaseDelay
+	for i := 1; i < excess && delay < g.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, g.opts.MaxDelay)
+}
+
+// Bans lists the active bans, longest first.
+func (g *Guard) Bans() []model.Ban {
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	bans := []model.Ban{}
+	for key, r := range g.records {
+		if now.Before(r.bannedUntil) {
+			bans = append(bans, model.Ban{Kind: key[0], Value: key[1], Failures: r.failures, Until: r.bannedUntil})
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Until.After(bans[j].Until) })
+	return bans
+}
+
+// Clear lifts a ban and forgets the failures behind it.
+func (g *Guard) Clear(ctx context.Context, kind, value string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := [2]string{kind, value}
+	r, ok := g.records[key]
+	if !ok || !g.now().Before(r.bannedUntil) {
+		return false
+	}
+	delete(g.records, key)
+	g.logger.InfoContext(ctx, "security event",
+		"log.type", "security",
+		"event", "auth.unban",
+		"ban.kind", kind,
+		"ban.value", value,
+	)
+	return true
+}
+
+// ClearAll lifts every ban and returns how many there were.
+func (g *Guard) ClearAll(ctx context.Context) int {
+	now := g.now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cleared := 0
+	for key, r := range g.records {
+		if now.Before(r.bannedUntil) {
+			cleared++
+		}
+		delete(g.records, key)
+	}
+	g.logger.InfoContext(ctx, "security event",
+		"log.type", "security",
+		"event", "auth.unban",
+		"bans", cleared,
+	)
+	return cleared
+}
+
+func (g *Guard) sweep(now time.Time) {
+	for key, r := range g.records {
+		if now.Sub(r.first) > g.opts.Window && !now.Before(r.bannedUntil) {
+			delete(g.records, key)
+		}
+	}
+	g.lastSweep = now
+}
+
+// evict makes room for a record by sweeping expired ones and, failing that,
+// dropping the oldest client that is not banned.
+func (g *Guard) evict(now time.Time) bool {
+	g.sweep(now)
+	if len(g.records) < g.opts.MaxRecords {
+		return true
+	}
+	var oldest [2]string
+	var oldestFirst time.Time
+	for key, r := range g.records {
+		if now.Before(r.bannedUntil) {
+			continue
+		}
+		if oldestFirst.IsZero() || r.first.Before(oldestFirst) {
+			oldest, oldestFirst = key, r.first
+		}
+	}
+	if oldestFirst.IsZero() {
+		return false
+	}
+	delete(g.records, oldest)
+	return true
+}
+
+// keys returns the records a failure counts against. Prefixes are chosen by
+// the client, so only ones that a generated key could have are tracked.
+func keys(ip, prefix string) [][2]string {
+	var keys [][2]string
+	if ip != "" {
+		keys = append(keys, [2]string{model.BanKindIP, ip})
+	}
+	if model.ValidAPIKeyPrefix(prefix) {
+		keys = append(keys, [2]string{model.BanKindPrefix, prefix})
+	}
+	return keys
+}
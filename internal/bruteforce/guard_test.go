@@ -0,0 +1,114 @@
+package bruteforce
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+)
+
+func newTestGuard(now *time.Time) *Guard {
+	g := NewGuard(slog.New(slog.NewTextHandler(io.Discard, nil)), Options{
+		Window:      time.Minute,
+		DelayAfter:  2,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    300 * time.Millisecond,
+		BanAfter:    5,
+		BanDuration: 10 * time.Minute,
+	})
+	g.now = func() time.Time { return *now }
+	return g
+}
+
+func TestGuard_DelaysAndBans(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	g := newTestGuard(&now)
+	ctx := context.Background()
+
+	want := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+	for i, expected := range want {
+		if delay := g.Failure(ctx, "10.0.0.1", "0123456789ab"); delay != expected {
+			t.Errorf("failure %d: expected delay %v, got %v", i+1, expected, delay)
+		}
+	}
+
+	if retryAfter, banned := g.Check("10.0.0.1", ""); !banned || retryAfter != 10*time.Minute {
+		t.Errorf("expected the address to be banned for 10m, got %v %v", retryAfter, banned)
+	}
+	if _, banned := g.Check("10.0.0.2", "0123456789ab"); !banned {
+		t.Error("expected the key prefix to be banned from any address")
+	}
+	if _, banned := g.Check("10.0.0.2", "ba9876543210"); banned {
+		t.Error("expected other clients not to be banned")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, banned := g.Check("10.0.0.1", "0123456789ab"); banned {
+		t.Error("expected the ban to expire")
+	}
+	if delay := g.Failure(ctx, "10.0.0.1", "0123456789ab"); delay != 0 {
+		t.Errorf("expected failures to be forgotten after the window, got delay %v", delay)
+	}
+}
+
+func TestGuard_Clear(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	g := newTestGuard(&now)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		g.Failure(ctx, "10.0.0.1", "0123456789ab")
+	}
+	if bans := g.Bans(); len(bans) != 2 {
+		t.Fatalf("expected 2 bans, got %+v", bans)
+	}
+
+	if !g.Clear(ctx, model.BanKindIP, "10.0.0.1") {
+		t.Error("expected the address ban to be cleared")
+	}
+	if g.Clear(ctx, model.BanKindIP, "10.0.0.1") {
+		t.Error("expected clearing a missing ban to report false")
+	}
+	if _, banned := g.Check("10.0.0.1", ""); banned {
+		t.Error("expected the address to be unbanned")
+	}
+	if cleared := g.ClearAll(ctx); cleared != 1 {
+		t.Errorf("expected 1 remaining ban to be cleared, got %d", cleared)
+	}
+	if bans := g.Bans(); len(bans) != 0 {
+		t.Errorf("expected no bans, got %+v", bans)
+	}
+}
+
+func TestGuard_BoundsRecords(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	g := NewGuard(slog.New(slog.DiscardHandler), Options{BanAfter: 2, MaxRecords: 3})
+	g.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	g.Failure(ctx, "", "not-a-prefix")
+	g.Failure(ctx, "", "0123456789AB")
+	if len(g.records) != 0 {
+		t.Fatalf("expected malformed prefixes not to be tracked, got %d records", len(g.records))
+	}
+
+	g.Failure(ctx, "10.0.0.1", "")
+	g.Failure(ctx, "10.0.0.1", "")
+	for i := 2; i <= 5; i++ {
+		now = now.Add(time.Second)
+		g.Failure(ctx, fmt.Sprintf("10.0.0.%d", i), "")
+	}
+	if len(g.records) != 3 {
+		t.Errorf("expected at most 3 records, got %d", len(g.records))
+	}
+	if _, banned := g.Check("10.0.0.1", ""); !banned {
+		t.Error("expected the ban to survive eviction")
+	}
+	if _, ok := g.records[[2]string{model.BanKindIP, "10.0.0.2"}]; ok {
+		t.Error("expected the oldest unbanned client to be evicted")
+	}
+}
@@ -0,0 +1,50 @@
+package certauth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+
+	"cruder/internal/model"
+)
+
+var ErrUnknownCertificate = errors.New("client certificate is not mapped to an identity")
+
+// Identity grants Scopes, Roles and optionally a Tenant to client
+// certificates whose subject common name is Subject.
+type Identity struct {
+	Subject string
+	Scopes  []string
+	Roles   []string
+	Tenant  string
+}
+
+// Authenticator maps client certificates that the TLS handshake already
+// verified against the CA bundle to principals. Only listed subjects are
+// accepted, so a CA shared with other services does not grant access on
+// its own.
+type Authenticator struct {
+	identities map[string]Identity
+}
+
+func NewAuthenticator(identities []Identity) *Authenticator {
+	a := &Authenticator{identities: make(map[string]Identity, len(identities))}
+	for _, identity := range identities {
+		a.identities[identity.Subject] = identity
+	}
+	return a
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, cert *x509.Certificate) (*model.Principal, error) {
+	identity, ok := a.identities[cert.Subject.CommonName]
+	if !ok || cert.Subject.CommonName == "" {
+		return nil, ErrUnknownCertificate
+	}
+	return &model.Principal{
+		Subject: identity.Subject,
+		Method:  model.AuthMethodClientCert,
+		Scopes:  identity.Scopes,
+		Roles:   identity.Roles,
+		Tenant:  identity.Tenant,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package certauth
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"slices"
+	"testing"
+
+	"cruder/internal/model"
+)
+
+func TestAuthenticator(t *testing.T) {
+	authenticator := NewAuthenticator([]Identity{
+		{Subject: "svc-reports", Scopes: []string{model.ScopeUsersRead}, Roles: []string{"viewer"}, Tenant: "acme"},
+		{Subject: ""},
+	})
+
+	principal, err := authenticator.Authenticate(context.Background(), &x509.Certificate{Subject: pkix.Name{CommonName: "svc-reports"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if principal.Subject != "svc-reports" || principal.Method != model.AuthMethodClientCert || principal.Tenant != "acme" ||
+		!slices.Equal(principal.Scopes, []string{model.ScopeUsersRead}) || !slices.Equal(principal.Roles, []string{"viewer"}) {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	for _, commonName := range []string{"svc-unknown", "SVC-REPORTS", ""} {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		if _, err := authenticator.Authenticate(context.Background(), cert); !errors.Is(err, ErrUnknownCertificate) {
+			t.Errorf("expected ErrUnknownCertificate for %q, got %v", commonName, err)
+		}
+	}
+}
@@ -2,22 +2,123 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"cruder/pkg/signing"
 
 	"gopkg.in/yaml.v3"
 )
 
+var dsnPassword = regexp.MustCompile(`password=('(?:[^'\\]|\\.)*'|\S*)`)
+
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	API      APIConfig      `yaml:"api"`
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	API       APIConfig       `yaml:"api"`
+	Tenancy   TenancyConfig   `yaml:"tenancy"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Health    HealthConfig    `yaml:"health"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	RequestID RequestIDConfig `yaml:"request_id"`
 }
 
 type ServerConfig struct {
 	Port int    `yaml:"port"`
 	Host string `yaml:"host"`
 	Env  string `yaml:"env"`
+
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers are believed. Without any, the client address is
+	// the connection's peer, so clients cannot pick the address that rate
+	// limits and bans apply to.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	TLS             TLSConfig             `yaml:"tls"`
+	CORS            CORSConfig            `yaml:"cors"`
+	SecurityHeaders SecurityHeadersConfig `yaml:"security_headers"`
+	BodyLimit       BodyLimitConfig       `yaml:"body_limit"`
+
+	Admin AdminConfig `yaml:"admin"`
+	Debug DebugConfig `yaml:"debug"`
+}
+
+// TLSConfig serves the API over HTTPS. The files are reloaded when they
+// change. With ClientAuth set to optional or require, client certificates
+// signed by ClientCAFile are verified, and those whose common name is listed
+// in ClientIdentities authenticate as that identity. The admin listener is
+// served with the same configuration; the debug listener stays plain HTTP.
+type TLSConfig struct {
+	Enabled          bool                   `yaml:"enabled"`
+	CertFile         string                 `yaml:"cert_file"`
+	KeyFile          string                 `yaml:"key_file"`
+	MinVersion       string                 `yaml:"min_version"`
+	CipherSuites     []string               `yaml:"cipher_suites"`
+	ReloadInterval   time.Duration          `yaml:"reload_interval"`
+	ClientAuth       string                 `yaml:"client_auth"`
+	ClientCAFile     string                 `yaml:"client_ca_file"`
+	ClientIdentities []ClientIdentityConfig `yaml:"client_identities"`
+}
+
+type ClientIdentityConfig struct {
+	Subject string   `yaml:"subject"`
+	Scopes  []string `yaml:"scopes"`
+	Roles   []string `yaml:"roles"`
+	Tenant  string   `yaml:"tenant"`
+}
+
+// CORSConfig lets browser apps on AllowedOrigins call the API. Origins are
+// exact, "*" or wildcard subdomains such as "https://*.example.com".
+type CORSConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+type SecurityHeadersConfig struct {
+	Enabled               bool          `yaml:"enabled"`
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age"`
+	HSTSIncludeSubdomains bool          `yaml:"hsts_include_subdomains"`
+	ContentSecurityPolicy string        `yaml:"content_security_policy"`
+	FrameOptions          string        `yaml:"frame_options"`
+	ReferrerPolicy        string        `yaml:"referrer_policy"`
+}
+
+// BodyLimitConfig caps request bodies at MaxBytes, or per route at Routes,
+// keyed by "METHOD /route" as registered, e.g. "POST /api/v1/users/".
+type BodyLimitConfig struct {
+	MaxBytes int64            `yaml:"max_bytes"`
+	Routes   map[string]int64 `yaml:"routes"`
+}
+
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+	Token   string `yaml:"token"`
+}
+
+type DebugConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`
+	Port    int    `yaml:"port"`
+	Token   string `yaml:"token"`
 }
 
 type DatabaseConfig struct {
@@ -27,10 +128,224 @@ type DatabaseConfig struct {
 	Password string `yaml:"password"`
 	DBName   string `yaml:"dbname"`
 	SSLMode  string `yaml:"sslmode"`
+	URL      string `yaml:"url"`
+
+	ConnectTimeout   time.Duration `yaml:"connect_timeout"`
+	ApplicationName  string        `yaml:"application_name"`
+	StatementTimeout time.Duration `yaml:"statement_timeout"`
+	SSLRootCert      string        `yaml:"sslrootcert"`
+	SSLCert          string        `yaml:"sslcert"`
+	SSLKey           string        `yaml:"sslkey"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+	ConnectRetry        RetryConfig   `yaml:"connect_retry"`
+	HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+
+	ReplicaDSNs          []string      `yaml:"replica_dsns"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"`
+	StickyWindow         time.Duration `yaml:"sticky_window"`
+	SessionHeader        string        `yaml:"session_header"`
+
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	ExplainSlowQueries bool          `yaml:"explain_slow_queries"`
+}
+
+type RetryConfig struct {
+	InitialInterval time.Duration `yaml:"initial_interval"`
+	MaxInterval     time.Duration `yaml:"max_interval"`
+	MaxWait         time.Duration `yaml:"max_wait"`
+	Multiplier      float64       `yaml:"multiplier"`
+	Jitter          float64       `yaml:"jitter"`
 }
 
 type APIConfig struct {
-	Key string `yaml:"key"`
+	Key  string        `yaml:"key"`
+	Keys APIKeysConfig `yaml:"keys"`
+	JWT  JWTConfig     `yaml:"jwt"`
+	RBAC RBACConfig    `yaml:"rbac"`
+
+	BruteForce BruteForceConfig `yaml:"brute_force"`
+	Signing    SigningConfig    `yaml:"signing"`
+}
+
+// SigningConfig accepts requests signed with a shared secret per key, as
+// produced by pkg/signing. Timestamps may be MaxSkew away from the server
+// clock, and nonces are remembered in NonceStore, memory or redis, to reject
+// replays; only redis catches replays sent to another replica.
+type SigningConfig struct {
+	Enabled    bool               `yaml:"enabled"`
+	MaxSkew    time.Duration      `yaml:"max_skew"`
+	NonceStore string             `yaml:"nonce_store"`
+	Redis      RedisConfig        `yaml:"redis"`
+	Keys       []SigningKeyConfig `yaml:"keys"`
+}
+
+type SigningKeyConfig struct {
+	ID     string   `yaml:"id"`
+	Secret string   `yaml:"secret"`
+	Scopes []string `yaml:"scopes"`
+	Roles  []string `yaml:"roles"`
+	Tenant string   `yaml:"tenant"`
+}
+
+// BruteForceConfig throttles failed API key authentication per client
+// address and key prefix: failures past DelayAfter within Window are
+// answered after a growing delay, and BanAfter failures ban the client for
+// BanDuration.
+type BruteForceConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Window      time.Duration `yaml:"window"`
+	DelayAfter  int           `yaml:"delay_after"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	MaxDelay    time.Duration `yaml:"max_delay"`
+	BanAfter    int           `yaml:"ban_after"`
+	BanDuration time.Duration `yaml:"ban_duration"`
+}
+
+type APIKeysConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	CacheTTL         time.Duration `yaml:"cache_ttl"`
+	LastUsedInterval time.Duration `yaml:"last_used_interval"`
+}
+
+// JWTConfig enables Authorization: Bearer tokens issued by an external
+// identity provider, verified against its JSON Web Key Set.
+type JWTConfig struct {
+	Enabled         bool                    `yaml:"enabled"`
+	Issuer          string                  `yaml:"issuer"`
+	Audience        string                  `yaml:"audience"`
+	JWKSURL         string                  `yaml:"jwks_url"`
+	JWKSFile        string                  `yaml:"jwks_file"`
+	RefreshInterval time.Duration           `yaml:"refresh_interval"`
+	Leeway          time.Duration           `yaml:"leeway"`
+	ScopeClaim      string                  `yaml:"scope_claim"`
+	RoleClaim       string                  `yaml:"role_claim"`
+	ClaimMappings   []JWTClaimMappingConfig `yaml:"claim_mappings"`
+}
+
+// JWTClaimMappingConfig grants Scopes and Roles to tokens whose Claim equals,
+// or for list claims contains, Value.
+type JWTClaimMappingConfig struct {
+	Claim  string   `yaml:"claim"`
+	Value  string   `yaml:"value"`
+	Scopes []string `yaml:"scopes"`
+	Roles  []string `yaml:"roles"`
+}
+
+// TenancyConfig controls how requests are scoped to a tenant: from the API
+// key or the JWT Claim when the credentials are bound to one. Unbound API
+// keys pick one with Header; every other caller falls back to DefaultTenant.
+type TenancyConfig struct {
+	Header        string `yaml:"header"`
+	Claim         string `yaml:"claim"`
+	DefaultTenant string `yaml:"default_tenant"`
+	// RowLevelSecurity backs the tenant filter in each query with the
+	// Postgres row-level security policy on the users table. The policy only
+	// binds a database user that does not own the table and is neither a
+	// superuser nor BYPASSRLS.
+	RowLevelSecurity bool `yaml:"row_level_security"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store is memory, limiting each replica on its own, or redis, sharing
+	// the limits between replicas.
+	Store     string                 `yaml:"store"`
+	Redis     RedisConfig            `yaml:"redis"`
+	PerIP     LimitConfig            `yaml:"per_ip"`
+	PerClient LimitConfig            `yaml:"per_client"`
+	Routes    map[string]LimitConfig `yaml:"routes"`
+}
+
+// LimitConfig is a token bucket of Requests per Period with room for Burst
+// requests at once. A zero Requests disables the limit.
+type LimitConfig struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+type RedisConfig struct {
+	Address   string `yaml:"address"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	DB        int    `yaml:"db"`
+	KeyPrefix string `yaml:"key_prefix"`
+}
+
+// RBACConfig enables role-based access control on the user routes, with
+// roles, field rules and bindings read from PolicyFile.
+type RBACConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	PolicyFile string `yaml:"policy_file"`
+}
+
+type HealthConfig struct {
+	Timeout         time.Duration `yaml:"timeout"`
+	CheckMigrations bool          `yaml:"check_migrations"`
+}
+
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	ServiceName string            `yaml:"service_name"`
+	Exporter    string            `yaml:"exporter"`
+	Endpoint    string            `yaml:"endpoint"`
+	Insecure    bool              `yaml:"insecure"`
+	Headers     map[string]string `yaml:"headers"`
+	File        string            `yaml:"file"`
+	SampleRatio float64           `yaml:"sample_ratio"`
+}
+
+type LoggingConfig struct {
+	Level     string          `yaml:"level"`
+	Format    string          `yaml:"format"`
+	Output    string          `yaml:"output"`
+	Access    AccessLogConfig `yaml:"access"`
+	Redaction RedactionConfig `yaml:"redaction"`
+}
+
+type RedactionConfig struct {
+	PathParams  []string `yaml:"path_params"`
+	QueryParams []string `yaml:"query_params"`
+	Headers     []string `yaml:"headers"`
+	Patterns    []string `yaml:"patterns"`
+}
+
+type RequestIDConfig struct {
+	Format          string `yaml:"format"`
+	MaxLength       int    `yaml:"max_length"`
+	TrustIncoming   bool   `yaml:"trust_incoming"`
+	FromTraceparent bool   `yaml:"from_traceparent"`
+	SQLComments     bool   `yaml:"sql_comments"`
+}
+
+type AccessLogConfig struct {
+	SampleRate       float64            `yaml:"sample_rate"`
+	RouteSampleRates map[string]float64 `yaml:"route_sample_rates"`
+	SlowThreshold    time.Duration      `yaml:"slow_threshold"`
+
+	Sinks      []string           `yaml:"sinks"`
+	BufferSize int                `yaml:"buffer_size"`
+	File       AccessFileConfig   `yaml:"file"`
+	Syslog     AccessSyslogConfig `yaml:"syslog"`
+}
+
+type AccessFileConfig struct {
+	Path       string        `yaml:"path"`
+	MaxSizeMB  int           `yaml:"max_size_mb"`
+	MaxAge     time.Duration `yaml:"max_age"`
+	MaxBackups int           `yaml:"max_backups"`
+	Compress   bool          `yaml:"compress"`
+}
+
+type AccessSyslogConfig struct {
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
 }
 
 func Load(configPath string) (*Config, error) {
@@ -39,17 +354,176 @@ func Load(configPath string) (*Config, error) {
 			Port: 8080,
 			Host: "0.0.0.0",
 			Env:  "development",
+
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownDelay:     5 * time.Second,
+			ShutdownTimeout:   25 * time.Second,
+
+			TLS: TLSConfig{
+				Enabled:        false,
+				MinVersion:     "1.2",
+				ReloadInterval: 30 * time.Second,
+				ClientAuth:     "none",
+			},
+			CORS: CORSConfig{
+				Enabled:        false,
+				AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE"},
+				AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-ID", "X-Tenant-ID", "X-Session-ID"},
+				ExposedHeaders: []string{"X-Request-ID", "X-Session-ID", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+				MaxAge:         10 * time.Minute,
+			},
+			SecurityHeaders: SecurityHeadersConfig{
+				Enabled:               true,
+				HSTSMaxAge:            365 * 24 * time.Hour,
+				HSTSIncludeSubdomains: true,
+				ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+				FrameOptions:          "DENY",
+				ReferrerPolicy:        "no-referrer",
+			},
+			BodyLimit: BodyLimitConfig{
+				MaxBytes: 1 << 20,
+			},
+
+			Admin: AdminConfig{
+				Enabled: true,
+				Host:    "0.0.0.0",
+				Port:    9090,
+			},
+			Debug: DebugConfig{
+				Enabled: false,
+				Host:    "127.0.0.1",
+				Port:    6060,
+			},
 		},
 		Database: DatabaseConfig{
-			Host:    "localhost",
-			Port:    5432,
-			User:    "postgres",
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
 			Password: "postgres",
-			DBName:  "postgres",
-			SSLMode: "disable",
+			DBName:   "postgres",
+			SSLMode:  "disable",
+
+			ConnectTimeout:  5 * time.Second,
+			ApplicationName: "cruder",
+
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ConnectRetry: RetryConfig{
+				InitialInterval: 500 * time.Millisecond,
+				MaxInterval:     10 * time.Second,
+				MaxWait:         2 * time.Minute,
+				Multiplier:      2,
+				Jitter:          0.2,
+			},
+			HealthCheckInterval: 5 * time.Second,
+
+			ReplicaCheckInterval: 5 * time.Second,
+			StickyWindow:         5 * time.Second,
+			SessionHeader:        "X-Session-ID",
+
+			SlowQueryThreshold: 200 * time.Millisecond,
+			ExplainSlowQueries: true,
 		},
 		API: APIConfig{
 			Key: "",
+			Keys: APIKeysConfig{
+				Enabled:          false,
+				CacheTTL:         time.Minute,
+				LastUsedInterval: time.Minute,
+			},
+			JWT: JWTConfig{
+				Enabled:         false,
+				RefreshInterval: time.Hour,
+				Leeway:          30 * time.Second,
+				ScopeClaim:      "scope",
+				RoleClaim:       "roles",
+			},
+			RBAC: RBACConfig{
+				Enabled:    false,
+				PolicyFile: "policy.yaml",
+			},
+			BruteForce: BruteForceConfig{
+				Enabled:     false,
+				Window:      15 * time.Minute,
+				DelayAfter:  3,
+				BaseDelay:   250 * time.Millisecond,
+				MaxDelay:    5 * time.Second,
+				BanAfter:    10,
+				BanDuration: 15 * time.Minute,
+			},
+			Signing: SigningConfig{
+				Enabled:    false,
+				MaxSkew:    5 * time.Minute,
+				NonceStore: "memory",
+				Redis: RedisConfig{
+					Address:   "localhost:6379",
+					KeyPrefix: "cruder:nonce:",
+				},
+			},
+		},
+		Tenancy: TenancyConfig{
+			Header:           "X-Tenant-ID",
+			Claim:            "tenant",
+			DefaultTenant:    "default",
+			RowLevelSecurity: false,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: false,
+			Store:   "memory",
+			Redis: RedisConfig{
+				Address:   "localhost:6379",
+				KeyPrefix: "cruder:ratelimit:",
+			},
+			PerIP:     LimitConfig{Requests: 300, Period: time.Minute, Burst: 50},
+			PerClient: LimitConfig{Requests: 600, Period: time.Minute, Burst: 100},
+		},
+		Health: HealthConfig{
+			Timeout:         2 * time.Second,
+			CheckMigrations: true,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+			Output: "stdout",
+			Access: AccessLogConfig{
+				SampleRate:    1,
+				SlowThreshold: time.Second,
+				Sinks:         []string{"stdout"},
+				BufferSize:    4096,
+				File: AccessFileConfig{
+					Path:       "access.log",
+					MaxSizeMB:  100,
+					MaxAge:     7 * 24 * time.Hour,
+					MaxBackups: 5,
+					Compress:   true,
+				},
+				Syslog: AccessSyslogConfig{
+					Tag: "cruder",
+				},
+			},
+			Redaction: RedactionConfig{
+				PathParams:  []string{"username"},
+				QueryParams: []string{"username", "email"},
+				Headers:     []string{"X-API-Key", "Authorization", "Cookie"},
+			},
+		},
+		RequestID: RequestIDConfig{
+			Format:        "uuidv7",
+			MaxLength:     64,
+			TrustIncoming: true,
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			ServiceName: "cruder",
+			Exporter:    "otlp",
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			File:        "traces.json",
+			SampleRatio: 1,
 		},
 	}
 
@@ -63,14 +537,147 @@ func Load(configPath string) (*Config, error) {
 	}
 	overrideFromEnv(config)
 
-	if config.Database.Host == "" {
-		return nil, fmt.Errorf("database host is required")
+	if config.Database.URL == "" {
+		if config.Database.Host == "" {
+			return nil, fmt.Errorf("database host is required")
+		}
+		if config.Database.User == "" {
+			return nil, fmt.Errorf("database user is required")
+		}
+		if config.Database.DBName == "" {
+			return nil, fmt.Errorf("database name is required")
+		}
+	} else if _, err := url.Parse(config.Database.URL); err != nil {
+		return nil, fmt.Errorf("database url is invalid: %w", err)
 	}
-	if config.Database.User == "" {
-		return nil, fmt.Errorf("database user is required")
+	if config.Server.Admin.Enabled && config.Server.Admin.Port == config.Server.Port {
+		return nil, fmt.Errorf("server admin port must differ from the server port")
 	}
-	if config.Database.DBName == "" {
-		return nil, fmt.Errorf("database name is required")
+	if config.Server.Debug.Enabled {
+		if config.Server.Debug.Token == "" {
+			return nil, fmt.Errorf("server debug token is required when the debug listener is enabled")
+		}
+		if config.Server.Debug.Port == config.Server.Port || (config.Server.Admin.Enabled && config.Server.Debug.Port == config.Server.Admin.Port) {
+			return nil, fmt.Errorf("server debug port must differ from the server and admin ports")
+		}
+	}
+	if tls := config.Server.TLS; tls.Enabled {
+		if tls.CertFile == "" || tls.KeyFile == "" {
+			return nil, fmt.Errorf("server tls cert_file and key_file are required when tls is enabled")
+		}
+		switch tls.MinVersion {
+		case "1.2", "1.3":
+		default:
+			return nil, fmt.Errorf("server tls min_version must be 1.2 or 1.3")
+		}
+		switch tls.ClientAuth {
+		case "none":
+		case "optional", "require":
+			if tls.ClientCAFile == "" {
+				return nil, fmt.Errorf("server tls client_ca_file is required when client_auth is %s", tls.ClientAuth)
+			}
+		default:
+			return nil, fmt.Errorf("server tls client_auth must be none, optional or require")
+		}
+	}
+	for _, proxy := range config.Server.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				return nil, fmt.Errorf("server trusted_proxies entry %q is not an address or CIDR", proxy)
+			}
+		}
+	}
+	if cors := config.Server.CORS; cors.Enabled {
+		if len(cors.AllowedOrigins) == 0 {
+			return nil, fmt.Errorf("server cors allowed_origins is required when cors is enabled")
+		}
+		if cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*") {
+			return nil, fmt.Errorf("server cors cannot allow credentials from any origin")
+		}
+	}
+	for route := range config.Server.BodyLimit.Routes {
+		if method, path, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("server body_limit route %q must be written as \"METHOD /path\"", route)
+		}
+	}
+	if config.API.JWT.Enabled {
+		if config.API.JWT.Issuer == "" || config.API.JWT.Audience == "" {
+			return nil, fmt.Errorf("api jwt issuer and audience are required when jwt is enabled")
+		}
+		if config.API.JWT.JWKSURL == "" && config.API.JWT.JWKSFile == "" {
+			return nil, fmt.Errorf("api jwt jwks_url or jwks_file is required when jwt is enabled")
+		}
+	}
+	if bf := config.API.BruteForce; bf.Enabled && (bf.Window <= 0 || bf.BanDuration <= 0 || bf.DelayAfter < 0 || bf.BanAfter < 0) {
+		return nil, fmt.Errorf("api brute_force window and ban_duration must be positive and thresholds non-negative")
+	}
+	if signingCfg := config.API.Signing; signingCfg.Enabled {
+		if signingCfg.MaxSkew <= 0 {
+			return nil, fmt.Errorf("api signing max_skew must be positive")
+		}
+		switch signingCfg.NonceStore {
+		case "memory", "redis":
+		default:
+			return nil, fmt.Errorf("api signing nonce_store must be memory or redis")
+		}
+		for _, key := range signingCfg.Keys {
+			if key.ID == "" || len(key.Secret) < 32 {
+				return nil, fmt.Errorf("api signing keys need an id and a secret of at least 32 characters")
+			}
+			if !signing.ValidToken(key.ID) {
+				return nil, fmt.Errorf("api signing key id %q may only contain letters, digits and -._~", key.ID)
+			}
+		}
+	}
+	if config.Tenancy.Header == "" {
+		return nil, fmt.Errorf("tenancy header is required")
+	}
+	switch config.RateLimit.Store {
+	case "memory", "redis":
+	default:
+		return nil, fmt.Errorf("rate_limit store must be memory or redis")
+	}
+	for route, limit := range config.RateLimit.Routes {
+		if method, path, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("rate_limit route %q must be written as \"METHOD /path\"", route)
+		}
+		if limit.Requests > 0 && limit.Period <= 0 {
+			return nil, fmt.Errorf("rate_limit route %q needs a period", route)
+		}
+	}
+	switch config.Logging.Format {
+	case "json", "text":
+	default:
+		return nil, fmt.Errorf("logging format must be json or text")
+	}
+	for _, sink := range config.Logging.Access.Sinks {
+		switch sink {
+		case "stdout", "stderr", "file", "syslog":
+		default:
+			return nil, fmt.Errorf("logging access sinks must be stdout, stderr, file or syslog")
+		}
+	}
+	switch config.RequestID.Format {
+	case "uuidv4", "uuidv7", "ulid":
+	default:
+		return nil, fmt.Errorf("request_id format must be one of uuidv4, uuidv7 or ulid")
+	}
+	if config.RequestID.MaxLength <= 0 || config.RequestID.MaxLength > 256 {
+		return nil, fmt.Errorf("request_id max_length must be between 1 and 256")
+	}
+	switch config.Tracing.Exporter {
+	case "otlp", "stdout", "file", "none":
+	default:
+		return nil, fmt.Errorf("tracing exporter must be one of otlp, stdout, file or none")
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample_ratio must be between 0 and 1")
+	}
+	if config.Database.ConnectRetry.Jitter < 0 || config.Database.ConnectRetry.Jitter > 1 {
+		return nil, fmt.Errorf("database connect_retry.jitter must be between 0 and 1")
+	}
+	if config.Database.MaxOpenConns > 0 && config.Database.MaxIdleConns > config.Database.MaxOpenConns {
+		return nil, fmt.Errorf("database max_idle_conns must not exceed max_open_conns")
 	}
 
 	return config, nil
@@ -88,6 +695,32 @@ func overrideFromEnv(config *Config) {
 	if env := os.Getenv("APP_ENV"); env != "" {
 		config.Server.Env = env
 	}
+	envDuration("SERVER_SHUTDOWN_DELAY", &config.Server.ShutdownDelay)
+	if proxies := os.Getenv("SERVER_TRUSTED_PROXIES"); proxies != "" {
+		config.Server.TrustedProxies = splitList(proxies)
+	}
+	envBool("ADMIN_ENABLED", &config.Server.Admin.Enabled)
+	envString("ADMIN_HOST", &config.Server.Admin.Host)
+	envInt("ADMIN_PORT", &config.Server.Admin.Port)
+	envString("ADMIN_TOKEN", &config.Server.Admin.Token)
+	envDuration("SERVER_SHUTDOWN_TIMEOUT", &config.Server.ShutdownTimeout)
+	envBool("DEBUG_ENABLED", &config.Server.Debug.Enabled)
+	envString("DEBUG_HOST", &config.Server.Debug.Host)
+	envInt("DEBUG_PORT", &config.Server.Debug.Port)
+	envString("DEBUG_TOKEN", &config.Server.Debug.Token)
+	envBool("TLS_ENABLED", &config.Server.TLS.Enabled)
+	envString("TLS_CERT_FILE", &config.Server.TLS.CertFile)
+	envString("TLS_KEY_FILE", &config.Server.TLS.KeyFile)
+	envString("TLS_MIN_VERSION", &config.Server.TLS.MinVersion)
+	envString("TLS_CLIENT_AUTH", &config.Server.TLS.ClientAuth)
+	envString("TLS_CLIENT_CA_FILE", &config.Server.TLS.ClientCAFile)
+	envBool("CORS_ENABLED", &config.Server.CORS.Enabled)
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		config.Server.CORS.AllowedOrigins = splitList(origins)
+	}
+	envBool("CORS_ALLOW_CREDENTIALS", &config.Server.CORS.AllowCredentials)
+	envBool("SECURITY_HEADERS_ENABLED", &config.Server.SecurityHeaders.Enabled)
+	envInt64("SERVER_MAX_BODY_BYTES", &config.Server.BodyLimit.MaxBytes)
 
 	if host := os.Getenv("POSTGRES_HOST"); host != "" {
 		config.Database.Host = host
@@ -109,20 +742,243 @@ func overrideFromEnv(config *Config) {
 	if sslmode := os.Getenv("POSTGRES_SSL_MODE"); sslmode != "" {
 		config.Database.SSLMode = sslmode
 	}
+	envString("DATABASE_URL", &config.Database.URL)
+	envDuration("POSTGRES_CONNECT_TIMEOUT", &config.Database.ConnectTimeout)
+	envString("POSTGRES_APPLICATION_NAME", &config.Database.ApplicationName)
+	envDuration("POSTGRES_STATEMENT_TIMEOUT", &config.Database.StatementTimeout)
+	envString("POSTGRES_SSL_ROOT_CERT", &config.Database.SSLRootCert)
+	envString("POSTGRES_SSL_CERT", &config.Database.SSLCert)
+	envString("POSTGRES_SSL_KEY", &config.Database.SSLKey)
+	envInt("POSTGRES_MAX_OPEN_CONNS", &config.Database.MaxOpenConns)
+	envInt("POSTGRES_MAX_IDLE_CONNS", &config.Database.MaxIdleConns)
+	envDuration("POSTGRES_CONN_MAX_LIFETIME", &config.Database.ConnMaxLifetime)
+	envDuration("POSTGRES_CONN_MAX_IDLE_TIME", &config.Database.ConnMaxIdleTime)
+
+	envDuration("POSTGRES_CONNECT_RETRY_INITIAL_INTERVAL", &config.Database.ConnectRetry.InitialInterval)
+	envDuration("POSTGRES_CONNECT_RETRY_MAX_INTERVAL", &config.Database.ConnectRetry.MaxInterval)
+	envDuration("POSTGRES_CONNECT_RETRY_MAX_WAIT", &config.Database.ConnectRetry.MaxWait)
+	envDuration("POSTGRES_HEALTH_CHECK_INTERVAL", &config.Database.HealthCheckInterval)
+
+	if replicas := os.Getenv("POSTGRES_REPLICA_DSNS"); replicas != "" {
+		config.Database.ReplicaDSNs = splitList(replicas)
+	}
+	envDuration("POSTGRES_REPLICA_CHECK_INTERVAL", &config.Database.ReplicaCheckInterval)
+	envDuration("POSTGRES_STICKY_WINDOW", &config.Database.StickyWindow)
+	envDuration("POSTGRES_SLOW_QUERY_THRESHOLD", &config.Database.SlowQueryThreshold)
+	envBool("POSTGRES_EXPLAIN_SLOW_QUERIES", &config.Database.ExplainSlowQueries)
 
 	if apiKey := os.Getenv("API_KEY"); apiKey != "" {
 		config.API.Key = apiKey
 	}
+	envBool("API_KEYS_ENABLED", &config.API.Keys.Enabled)
+	envDuration("API_KEYS_CACHE_TTL", &config.API.Keys.CacheTTL)
+	envBool("JWT_ENABLED", &config.API.JWT.Enabled)
+	envString("JWT_ISSUER", &config.API.JWT.Issuer)
+	envString("JWT_AUDIENCE", &config.API.JWT.Audience)
+	envString("JWT_JWKS_URL", &config.API.JWT.JWKSURL)
+	envString("JWT_JWKS_FILE", &config.API.JWT.JWKSFile)
+	envBool("RBAC_ENABLED", &config.API.RBAC.Enabled)
+	envString("RBAC_POLICY_FILE", &config.API.RBAC.PolicyFile)
+	envBool("BRUTE_FORCE_ENABLED", &config.API.BruteForce.Enabled)
+	envDuration("BRUTE_FORCE_WINDOW", &config.API.BruteForce.Window)
+	envInt("BRUTE_FORCE_DELAY_AFTER", &config.API.BruteForce.DelayAfter)
+	envInt("BRUTE_FORCE_BAN_AFTER", &config.API.BruteForce.BanAfter)
+	envDuration("BRUTE_FORCE_BAN_DURATION", &config.API.BruteForce.BanDuration)
+	envBool("SIGNING_ENABLED", &config.API.Signing.Enabled)
+	envDuration("SIGNING_MAX_SKEW", &config.API.Signing.MaxSkew)
+	envString("SIGNING_NONCE_STORE", &config.API.Signing.NonceStore)
+	envString("SIGNING_REDIS_ADDRESS", &config.API.Signing.Redis.Address)
+	envString("SIGNING_REDIS_PASSWORD", &config.API.Signing.Redis.Password)
+
+	envString("TENANCY_HEADER", &config.Tenancy.Header)
+	envString("TENANCY_DEFAULT_TENANT", &config.Tenancy.DefaultTenant)
+	envBool("TENANCY_ROW_LEVEL_SECURITY", &config.Tenancy.RowLevelSecurity)
+
+	envBool("RATE_LIMIT_ENABLED", &config.RateLimit.Enabled)
+	envString("RATE_LIMIT_STORE", &config.RateLimit.Store)
+	envString("RATE_LIMIT_REDIS_ADDRESS", &config.RateLimit.Redis.Address)
+	envString("RATE_LIMIT_REDIS_USERNAME", &config.RateLimit.Redis.Username)
+	envString("RATE_LIMIT_REDIS_PASSWORD", &config.RateLimit.Redis.Password)
+
+	envString("LOG_LEVEL", &config.Logging.Level)
+	envString("LOG_FORMAT", &config.Logging.Format)
+	envString("LOG_OUTPUT", &config.Logging.Output)
+	if sinks := os.Getenv("ACCESS_LOG_SINKS"); sinks != "" {
+		config.Logging.Access.Sinks = splitList(sinks)
+	}
+	envString("ACCESS_LOG_FILE", &config.Logging.Access.File.Path)
+	envString("ACCESS_LOG_SYSLOG_ADDRESS", &config.Logging.Access.Syslog.Address)
+
+	envString("REQUEST_ID_FORMAT", &config.RequestID.Format)
+	envBool("REQUEST_ID_TRUST_INCOMING", &config.RequestID.TrustIncoming)
+	envBool("REQUEST_ID_FROM_TRACEPARENT", &config.RequestID.FromTraceparent)
+	envBool("REQUEST_ID_SQL_COMMENTS", &config.RequestID.SQLComments)
+
+	envBool("TRACING_ENABLED", &config.Tracing.Enabled)
+	envString("TRACING_EXPORTER", &config.Tracing.Exporter)
+	envString("TRACING_ENDPOINT", &config.Tracing.Endpoint)
+
+	envDuration("HEALTH_CHECK_TIMEOUT", &config.Health.Timeout)
+	envBool("HEALTH_CHECK_MIGRATIONS", &config.Health.CheckMigrations)
+}
+
+// Warnings lists settings that are valid but probably not what the operator
+// meant. They are logged at startup.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.API.BruteForce.Enabled && len(c.Server.TrustedProxies) == 0 {
+		warnings = append(warnings, "api brute_force is enabled without server trusted_proxies: behind a proxy or load balancer every client shares the proxy's address, so one client's failures ban all of them")
+	}
+	return warnings
+}
+
+// Sanitized returns a copy of the configuration with passwords, keys and
+// tokens masked, suitable for diagnostics output.
+func (c *Config) Sanitized() Config {
+	const mask = "********"
+	masked := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return mask
+	}
+
+	s := *c
+	s.Database.Password = masked(s.Database.Password)
+	s.Database.URL = maskDSN(s.Database.URL)
+	s.Database.ReplicaDSNs = make([]string, len(c.Database.ReplicaDSNs))
+	for i, dsn := range c.Database.ReplicaDSNs {
+		s.Database.ReplicaDSNs[i] = maskDSN(dsn)
+	}
+	s.API.Key = masked(s.API.Key)
+	s.RateLimit.Redis.Password = masked(s.RateLimit.Redis.Password)
+	s.API.Signing.Redis.Password = masked(s.API.Signing.Redis.Password)
+	s.API.Signing.Keys = make([]SigningKeyConfig, len(c.API.Signing.Keys))
+	for i, key := range c.API.Signing.Keys {
+		key.Secret = masked(key.Secret)
+		s.API.Signing.Keys[i] = key
+	}
+	s.Server.Admin.Token = masked(s.Server.Admin.Token)
+	s.Server.Debug.Token = masked(s.Server.Debug.Token)
+	s.Tracing.Headers = make(map[string]string, len(c.Tracing.Headers))
+	for name, value := range c.Tracing.Headers {
+		s.Tracing.Headers[name] = masked(value)
+	}
+	return s
+}
+
+// maskDSN hides the password in a URL or key/value connection string.
+func maskDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password=********")
 }
 
 func (c *Config) GetDSN() string {
-	return fmt.Sprintf(
+	db := c.Database
+	options := [][2]string{
+		{"application_name", db.ApplicationName},
+		{"sslrootcert", db.SSLRootCert},
+		{"sslcert", db.SSLCert},
+		{"sslkey", db.SSLKey},
+	}
+	if db.ConnectTimeout > 0 {
+		options = append(options, [2]string{"connect_timeout", strconv.Itoa(max(1, int(db.ConnectTimeout.Seconds())))})
+	}
+	if db.StatementTimeout > 0 {
+		options = append(options, [2]string{"statement_timeout", strconv.FormatInt(db.StatementTimeout.Milliseconds(), 10)})
+	}
+
+	if db.URL != "" {
+		return urlDSN(db.URL, options)
+	}
+
+	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Database.Host,
-		c.Database.Port,
-		c.Database.User,
-		c.Database.Password,
-		c.Database.DBName,
-		c.Database.SSLMode,
+		quoteDSNValue(db.Host),
+		db.Port,
+		quoteDSNValue(db.User),
+		quoteDSNValue(db.Password),
+		quoteDSNValue(db.DBName),
+		quoteDSNValue(db.SSLMode),
 	)
-}
\ No newline at end of file
+	for _, option := range options {
+		if option[1] != "" {
+			dsn += " " + option[0] + "=" + quoteDSNValue(option[1])
+		}
+	}
+	return dsn
+}
+
+func urlDSN(raw string, options [][2]string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	query := u.Query()
+	for _, option := range options {
+		if option[1] != "" && !query.Has(option[0]) {
+			query.Set(option[0], option[1])
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+func envString(name string, target *string) {
+	if value := os.Getenv(name); value != "" {
+		*target = value
+	}
+}
+
+func envInt(name string, target *int) {
+	if value := os.Getenv(name); value != "" {
+		if v, err := strconv.Atoi(value); err == nil {
+			*target = v
+		}
+	}
+}
+
+func envInt64(name string, target *int64) {
+	if value := os.Getenv(name); value != "" {
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			*target = v
+		}
+	}
+}
+
+func envBool(name string, target *bool) {
+	if value := os.Getenv(name); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			*target = b
+		}
+	}
+}
+
+func envDuration(name string, target *time.Duration) {
+	if value := os.Getenv(name); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			*target = d
+		}
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -1,13 +1,24 @@
 package controller
 
-import "cruder/internal/service"
+import (
+	"log/slog"
+
+	"cruder/internal/health"
+	"cruder/internal/service"
+)
 
 type Controller struct {
-	Users *UserController
+	Users    *UserController
+	Database *DatabaseController
+	Health   *HealthController
+	Version  *VersionController
 }
 
-func NewController(services *service.Service) *Controller {
+func NewController(services *service.Service, registry *health.Registry, logger *slog.Logger) *Controller {
 	return &Controller{
-		Users: NewUserController(services.Users),
+		Users:    NewUserController(services.Users, logger),
+		Database: NewDatabaseController(services.Database),
+		Health:   NewHealthController(registry),
+		Version:  NewVersionController(services.Database),
 	}
 }
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"net/http"
+
+	"cruder/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DatabaseController struct {
+	service service.DatabaseService
+}
+
+func NewDatabaseController(service service.DatabaseService) *DatabaseController {
+	return &DatabaseController{service: service}
+}
+
+func (c *DatabaseController) GetStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"pools": c.service.Stats()})
+}
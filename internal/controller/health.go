@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"cruder/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	registry *health.Registry
+}
+
+func NewHealthController(registry *health.Registry) *HealthController {
+	return &HealthController{registry: registry}
+}
+
+func (c *HealthController) Liveness(ctx *gin.Context) {
+	c.respond(ctx, c.registry.Liveness)
+}
+
+func (c *HealthController) Readiness(ctx *gin.Context) {
+	c.respond(ctx, c.registry.Readiness)
+}
+
+func (c *HealthController) Startup(ctx *gin.Context) {
+	c.respond(ctx, c.registry.Startup)
+}
+
+func (c *HealthController) respond(ctx *gin.Context, run func(context.Context) health.Report) {
+	report := run(ctx.Request.Context())
+	if !report.Passed() {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
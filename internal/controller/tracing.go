@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"context"
+
+	"cruder/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func startSpan(ctx *gin.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx.Request.Context(), name)
+}
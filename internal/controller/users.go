@@ -1,7 +1,10 @@
 package controller
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -13,16 +16,43 @@ import (
 
 type UserController struct {
 	service service.UserService
+	logger  *slog.Logger
 }
 
-func NewUserController(service service.UserService) *UserController {
-	return &UserController{service: service}
+func NewUserController(service service.UserService, logger *slog.Logger) *UserController {
+	return &UserController{service: service, logger: logger}
+}
+
+// internalError logs the underlying error and returns a generic message, since
+// database errors can carry user data that must not reach the client.
+func (c *UserController) internalError(ctx *gin.Context, reqCtx context.Context, err error) {
+	c.logger.ErrorContext(reqCtx, "request failed", "handler", ctx.HandlerName(), "error", err)
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+}
+
+// bindJSON decodes the request body into req, answering 413 when the body
+// limit cut it short and 400 for anything else.
+func bindJSON(ctx *gin.Context, req any) bool {
+	err := ctx.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+	} else {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+	return false
 }
 
 func (c *UserController) GetAllUsers(ctx *gin.Context) {
-	users, err := c.service.GetAll()
+	reqCtx, span := startSpan(ctx, "UserController.GetAllUsers")
+	defer span.End()
+
+	users, err := c.service.GetAll(reqCtx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.internalError(ctx, reqCtx, err)
 		return
 	}
 
@@ -30,15 +60,18 @@ func (c *UserController) GetAllUsers(ctx *gin.Context) {
 }
 
 func (c *UserController) GetUserByUsername(ctx *gin.Context) {
+	reqCtx, span := startSpan(ctx, "UserController.GetUserByUsername")
+	defer span.End()
+
 	username := ctx.Param("username")
 
-	user, err := c.service.GetByUsername(username)
+	user, err := c.service.GetByUsername(reqCtx, username)
 	if err != nil {
 		if _, ok := err.(*model.ValidationError); ok {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.internalError(ctx, reqCtx, err)
 		return
 	}
 
@@ -46,6 +79,9 @@ func (c *UserController) GetUserByUsername(ctx *gin.Context) {
 }
 
 func (c *UserController) GetUserByID(ctx *gin.Context) {
+	reqCtx, span := startSpan(ctx, "UserController.GetUserByID")
+	defer span.End()
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -53,13 +89,13 @@ func (c *UserController) GetUserByID(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.GetByID(id)
+	user, err := c.service.GetByID(reqCtx, id)
 	if err != nil {
 		if _, ok := err.(*model.ValidationError); ok {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.internalError(ctx, reqCtx, err)
 		return
 	}
 
@@ -67,19 +103,21 @@ func (c *UserController) GetUserByID(ctx *gin.Context) {
 }
 
 func (c *UserController) CreateUser(ctx *gin.Context) {
+	reqCtx, span := startSpan(ctx, "UserController.CreateUser")
+	defer span.End()
+
 	var req model.CreateUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	user, err := c.service.Create(&req)
+	user, err := c.service.Create(reqCtx, &req)
 	if err != nil {
 		if _, ok := err.(*model.ValidationError); ok {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.internalError(ctx, reqCtx, err)
 		return
 	}
 
@@ -87,21 +125,23 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 }
 
 func (c *UserController) UpdateUser(ctx *gin.Context) {
+	reqCtx, span := startSpan(ctx, "UserController.UpdateUser")
+	defer span.End()
+
 	uuid := ctx.Param("uuid")
 
 	var req model.UpdateUserRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(ctx, &req) {
 		return
 	}
 
-	user, err := c.service.Update(uuid, &req)
+	user, err := c.service.Update(reqCtx, uuid, &req)
 	if err != nil {
 		if _, ok := err.(*model.ValidationError); ok {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.internalError(ctx, reqCtx, err)
 		return
 	}
 
@@ -109,9 +149,12 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 }
 
 func (c *UserController) DeleteUser(ctx *gin.Context) {
+	reqCtx, span := startSpan(ctx, "UserController.DeleteUser")
+	defer span.End()
+
 	uuid := ctx.Param("uuid")
 
-	err := c.service.Delete(uuid)
+	err := c.service.Delete(reqCtx, uuid)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
@@ -121,7 +164,7 @@ func (c *UserController) DeleteUser(ctx *gin.Context) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.internalError(ctx, reqCtx, err)
 		return
 	}
 
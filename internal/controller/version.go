@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"net/http"
+
+	"cruder/internal/service"
+	"cruder/internal/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VersionController struct {
+	service service.DatabaseService
+}
+
+func NewVersionController(service service.DatabaseService) *VersionController {
+	return &VersionController{service: service}
+}
+
+func (c *VersionController) GetVersion(ctx *gin.Context) {
+	response := gin.H{"build": version.Get()}
+
+	migrations, err := c.service.Migrations(ctx.Request.Context())
+	if err != nil {
+		response["migrations"] = gin.H{"error": "migration version unavailable"}
+	} else {
+		response["migrations"] = migrations
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -1,27 +1,85 @@
 package handler
 
 import (
+	"log/slog"
+
 	"cruder/internal/controller"
 	"cruder/internal/middleware"
+	"cruder/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
 
-func New(router *gin.Engine, userController *controller.UserController, apiKey string) *gin.Engine {
-	router.Use(middleware.RequestIDMiddleware())
-	router.Use(middleware.JSONLoggerMiddleware())
-	router.Use(middleware.APIKeyMiddleware(apiKey))
+type Options struct {
+	AccessLogger      *slog.Logger
+	AccessLog         middleware.AccessLogOptions
+	RequestID         middleware.RequestIDOptions
+	CORS              *middleware.CORSOptions
+	SecurityHeaders   *middleware.SecurityHeadersOptions
+	BodyLimit         middleware.BodyLimitOptions
+	Auth              middleware.AuthOptions
+	Authorizer        middleware.Authorizer
+	Tenants           middleware.TenantResolver
+	Tenant            middleware.TenantOptions
+	RateLimit         middleware.RateLimitOptions
+	SessionHeader     string
+	DatabaseAvailable func() bool
+}
+
+func New(router *gin.Engine, controllers *controller.Controller, opts Options) *gin.Engine {
+	router.Use(middleware.RequestIDMiddleware(opts.RequestID))
+	router.Use(middleware.TracingMiddleware())
+	router.Use(middleware.JSONLoggerMiddleware(opts.AccessLogger, opts.AccessLog))
+	router.Use(middleware.MetricsMiddleware())
+	if opts.SecurityHeaders != nil {
+		router.Use(middleware.SecurityHeadersMiddleware(*opts.SecurityHeaders))
+	}
+	if opts.CORS != nil {
+		router.Use(middleware.CORSMiddleware(*opts.CORS))
+	}
+	router.Use(middleware.BodyLimitMiddleware(opts.BodyLimit))
+
+	router.GET("/healthz", controllers.Health.Liveness)
+	router.GET("/readyz", controllers.Health.Readiness)
+	router.GET("/startupz", controllers.Health.Startup)
+	router.GET("/version", controllers.Version.GetVersion)
+
+	userController := controllers.Users
+	ipLimit := middleware.RateLimitMiddleware(opts.RateLimit, middleware.RateLimitIP)
+	clientLimit := middleware.RateLimitMiddleware(opts.RateLimit, middleware.RateLimitClient, middleware.RateLimitRoute)
 
 	v1 := router.Group("/api/v1")
+	v1.Use(middleware.ReadConsistencyMiddleware(opts.SessionHeader))
 	{
+		// Users are reachable by services with API keys, client
+		// certificates or signed requests and by people signed in through
+		// the identity provider; database stats stay service-only.
 		userGroup := v1.Group("/users")
+		userGroup.Use(ipLimit)
+		userGroup.Use(middleware.AuthMiddleware(opts.Auth, middleware.SchemeAPIKey, middleware.SchemeBearer, middleware.SchemeClientCert, middleware.SchemeSignature))
+		userGroup.Use(clientLimit)
+		userGroup.Use(middleware.TenantMiddleware(opts.Tenants, opts.Tenant))
+		userGroup.Use(middleware.DatabaseAvailabilityMiddleware(opts.DatabaseAvailable))
+		{
+			read := middleware.Authorize(opts.Authorizer, model.ScopeUsersRead)
+			write := middleware.Authorize(opts.Authorizer, model.ScopeUsersWrite)
+			remove := middleware.Authorize(opts.Authorizer, model.ScopeUsersDelete)
+			fields := middleware.AuthorizeFields(opts.Authorizer, "users")
+
+			userGroup.GET("/", read, userController.GetAllUsers)
+			userGroup.GET("/username/:username", read, userController.GetUserByUsername)
+			userGroup.GET("/id/:id", read, userController.GetUserByID)
+			userGroup.POST("/", write, userController.CreateUser)
+			userGroup.PATCH("/:uuid", write, fields, userController.UpdateUser)
+			userGroup.DELETE("/:uuid", remove, userController.DeleteUser)
+		}
+
+		databaseGroup := v1.Group("/database")
+		databaseGroup.Use(ipLimit)
+		databaseGroup.Use(middleware.AuthMiddleware(opts.Auth, middleware.SchemeAPIKey, middleware.SchemeClientCert, middleware.SchemeSignature))
+		databaseGroup.Use(clientLimit)
 		{
-			userGroup.GET("/", userController.GetAllUsers)
-			userGroup.GET("/username/:username", userController.GetUserByUsername)
-			userGroup.GET("/id/:id", userController.GetUserByID)
-			userGroup.POST("/", userController.CreateUser)
-			userGroup.PATCH("/:uuid", userController.UpdateUser)
-			userGroup.DELETE("/:uuid", userController.DeleteUser)
+			databaseGroup.GET("/stats", controllers.Database.GetStats)
 		}
 	}
 	return router
@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+)
+
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string {
+	return c.name
+}
+
+func (c checkerFunc) Check(ctx context.Context) error {
+	return c.fn(ctx)
+}
+
+func NewChecker(name string, fn func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, fn: fn}
+}
+
+type CheckResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+func (r Report) Passed() bool {
+	return r.Status == StatusPass
+}
+
+type Registry struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []Checker
+	readiness []Checker
+	startup   []Checker
+
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{timeout: timeout}
+}
+
+func (r *Registry) AddLiveness(checkers ...Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, checkers...)
+}
+
+func (r *Registry) AddReadiness(checkers ...Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, checkers...)
+}
+
+func (r *Registry) AddStartup(checkers ...Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startup = append(r.startup, checkers...)
+}
+
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.mu.RLock()
+	checkers := r.liveness
+	r.mu.RUnlock()
+	return r.run(ctx, checkers)
+}
+
+func (r *Registry) Readiness(ctx context.Context) Report {
+	if r.shuttingDown.Load() {
+		return Report{
+			Status: StatusFail,
+			Checks: []CheckResult{{Name: "shutdown", Status: StatusFail, Error: "server is shutting down"}},
+		}
+	}
+
+	r.mu.RLock()
+	checkers := r.readiness
+	r.mu.RUnlock()
+	return r.run(ctx, checkers)
+}
+
+// SetShuttingDown makes readiness fail from now on so the instance is taken
+// out of rotation while in-flight requests drain.
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// Startup runs the startup checks until they pass once; after that it keeps
+// reporting success so a later dependency outage only affects readiness.
+func (r *Registry) Startup(ctx context.Context) Report {
+	if r.started.Load() {
+		return Report{Status: StatusPass, Checks: []CheckResult{}}
+	}
+
+	r.mu.RLock()
+	checkers := r.startup
+	r.mu.RUnlock()
+
+	report := r.run(ctx, checkers)
+	if report.Passed() {
+		r.started.Store(true)
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, checkers []Checker) Report {
+	report := Report{Status: StatusPass, Checks: make([]CheckResult, len(checkers))}
+
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			report.Checks[i] = r.check(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusPass {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (r *Registry) check(ctx context.Context, checker Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+
+	result := CheckResult{
+		Name:       checker.Name(),
+		Status:     StatusPass,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
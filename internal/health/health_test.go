@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func sleeper(name string, d time.Duration) Checker {
+	return NewChecker(name, func(ctx context.Context) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func TestRegistry_RunsChecksInParallel(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.AddReadiness(sleeper("db", 100*time.Millisecond), sleeper("cache", 100*time.Millisecond), sleeper("queue", 100*time.Millisecond))
+
+	start := time.Now()
+	report := registry.Readiness(context.Background())
+	if elapsed := time.Since(start); elapsed >= 250*time.Millisecond {
+		t.Errorf("expected checks to run in parallel, took %v", elapsed)
+	}
+	if !report.Passed() || len(report.Checks) != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for i, name := range []string{"db", "cache", "queue"} {
+		if report.Checks[i].Name != name {
+			t.Errorf("expected check %d to be %s, got %s", i, name, report.Checks[i].Name)
+		}
+	}
+}
+
+func TestRegistry_TimesOutEachCheck(t *testing.T) {
+	registry := NewRegistry(50 * time.Millisecond)
+	registry.AddLiveness(sleeper("slow", time.Second), sleeper("fast", 0))
+
+	start := time.Now()
+	report := registry.Liveness(context.Background())
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("expected the slow check to be cut off, took %v", elapsed)
+	}
+	if report.Passed() {
+		t.Fatal("expected the report to fail")
+	}
+	if slow := report.Checks[0]; slow.Status != StatusFail || slow.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected the slow check to time out, got %+v", slow)
+	}
+	if fast := report.Checks[1]; fast.Status != StatusPass {
+		t.Errorf("expected the fast check to pass, got %+v", fast)
+	}
+}
+
+func TestRegistry_StartupLatches(t *testing.T) {
+	var fail atomic.Bool
+	var calls atomic.Int32
+	fail.Store(true)
+
+	registry := NewRegistry(time.Second)
+	registry.AddStartup(NewChecker("migrations", func(ctx context.Context) error {
+		calls.Add(1)
+		if fail.Load() {
+			return errors.New("pending migrations")
+		}
+		return nil
+	}))
+
+	if report := registry.Startup(context.Background()); report.Passed() {
+		t.Fatal("expected startup to fail before the check passes")
+	}
+	fail.Store(false)
+	if report := registry.Startup(context.Background()); !report.Passed() {
+		t.Fatalf("expected startup to pass, got %+v", report)
+	}
+
+	fail.Store(true)
+	if report := registry.Startup(context.Background()); !report.Passed() {
+		t.Errorf("expected startup to keep passing once it has, got %+v", report)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected the check to stop running after it passed, ran %d times", calls.Load())
+	}
+}
+
+func TestRegistry_SetShuttingDown(t *testing.T) {
+	registry := NewRegistry(time.Second)
+	registry.AddLiveness(sleeper("process", 0))
+	registry.AddReadiness(sleeper("db", 0))
+
+	if report := registry.Readiness(context.Background()); !report.Passed() {
+		t.Fatalf("expected readiness to pass, got %+v", report)
+	}
+
+	registry.SetShuttingDown()
+
+	report := registry.Readiness(context.Background())
+	if report.Passed() || len(report.Checks) != 1 || report.Checks[0].Name != "shutdown" {
+		t.Errorf("expected readiness to fail with the shutdown check, got %+v", report)
+	}
+	if report := registry.Liveness(context.Background()); !report.Passed() {
+		t.Errorf("expected liveness to keep passing while draining, got %+v", report)
+	}
+}
@@ -0,0 +1,292 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrUnavailable is returned when the key set could not be loaded, so
+	// callers can tell an outage from a bad token.
+	ErrUnavailable = errors.New("jwks unavailable")
+)
+
+type KeySetOptions struct {
+	// URL or File is the source of the JSON Web Key Set; URL wins when both
+	// are set.
+	URL  string
+	File string
+	// RefreshInterval is how often the set is reloaded in the background.
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits reloads triggered by tokens signed with an
+	// unknown key id, which is how a rotation is picked up early.
+	MinRefreshInterval time.Duration
+	HTTPClient         *http.Client
+	Logger             *slog.Logger
+}
+
+// KeySet caches the public keys of a JWKS and reloads them periodically and
+// whenever a token references a key id it has not seen.
+type KeySet struct {
+	opts KeySetOptions
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+	refreshMu   sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewKeySet(ctx context.Context, opts KeySetOptions) (*KeySet, error) {
+	if opts.URL == "" && opts.File == "" {
+		return nil, errors.New("jwks url or file is required")
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Hour
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = 30 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	ks := &KeySet{
+		opts: opts,
+		keys: make(map[string]crypto.PublicKey),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	// A failed first load is not fatal for a remote set: the identity
+	// provider may be briefly unavailable, and tokens trigger a reload.
+	if err := ks.refresh(ctx); err != nil {
+		if opts.URL == "" {
+			return nil, err
+		}
+		opts.Logger.WarnContext(ctx, "failed to load jwks", "url", opts.URL, "error", err)
+	}
+	go ks.run()
+	return ks, nil
+}
+
+// Key returns the public key with the given id, reloading the set once if
+// the id is unknown and the last reload is older than MinRefreshInterval.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := ks.lookup(kid); ok {
+		return key, nil
+	}
+
+	ks.mu.RLock()
+	recent := time.Since(ks.lastRefresh) < ks.opts.MinRefreshInterval
+	ks.mu.RUnlock()
+	if !recent {
+		if err := ks.refresh(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		if key, ok := ks.lookup(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *KeySet) run() {
+	defer close(ks.done)
+
+	ticker := time.NewTicker(ks.opts.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ks.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), ks.opts.HTTPClient.Timeout)
+			if err := ks.refresh(ctx); err != nil {
+				ks.opts.Logger.Warn("failed to refresh jwks", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (ks *KeySet) refresh(ctx context.Context) error {
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+
+	data, err := ks.load(ctx)
+
+	ks.mu.Lock()
+	ks.lastRefresh = time.Now()
+	ks.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseKeySet(data, ks.opts.Logger)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *KeySet) load(ctx context.Context) ([]byte, error) {
+	if ks.opts.URL == "" {
+		data, err := os.ReadFile(ks.opts.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwks file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ks.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (ks *KeySet) Close() error {
+	select {
+	case <-ks.done:
+		return nil
+	default:
+	}
+	close(ks.stop)
+	<-ks.done
+	return nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseKeySet decodes the signature keys of a JWKS. Keys of unsupported
+// types are skipped so one exotic key does not break the whole set.
+func parseKeySet(data []byte, logger *slog.Logger) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			logger.Warn("skipping jwks key", "kid", k.Kid, "error", err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,139 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"cruder/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Methods are the accepted signing algorithms. HMAC is deliberately absent:
+// the key set only holds public keys.
+var Methods = []string{"RS256", "ES256", "EdDSA"}
+
+// ClaimMapping grants Scopes and Roles to tokens whose Claim equals, or for
+// list claims contains, Value.
+type ClaimMapping struct {
+	Claim  string
+	Value  string
+	Scopes []string
+	Roles  []string
+}
+
+type VerifierOptions struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+	// ScopeClaim names the claim holding the token's scopes, either a space
+	// separated string (scope) or a list (scp). Defaults to "scope".
+	ScopeClaim string
+	// RoleClaim names the claim holding the token's roles. Defaults to
+	// "roles".
+	RoleClaim string
+	// TenantClaim names the claim holding the slug of the tenant the token
+	// is bound to. Tokens without it may pick a tenant per request.
+	TenantClaim   string
+	ClaimMappings []ClaimMapping
+}
+
+type Verifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+	opts   VerifierOptions
+}
+
+func NewVerifier(keys *KeySet, opts VerifierOptions) *Verifier {
+	if opts.ScopeClaim == "" {
+		opts.ScopeClaim = "scope"
+	}
+	if opts.RoleClaim == "" {
+		opts.RoleClaim = "roles"
+	}
+	return &Verifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(Methods),
+			jwt.WithIssuer(opts.Issuer),
+			jwt.WithAudience(opts.Audience),
+			jwt.WithLeeway(opts.Leeway),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+		opts: opts,
+	}
+}
+
+// Verify checks the token's signature and registered claims and returns the
+// principal it identifies. Every rejection wraps model.ErrInvalidToken; an
+// error that does not is an outage of the key set.
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (*model.Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidToken, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", model.ErrInvalidToken)
+	}
+
+	principal := &model.Principal{
+		Subject: subject,
+		Method:  model.AuthMethodJWT,
+		Scopes:  appendUnique(nil, claimValues(claims[v.opts.ScopeClaim])...),
+		Roles:   appendUnique(nil, claimValues(claims[v.opts.RoleClaim])...),
+	}
+	if v.opts.TenantClaim != "" {
+		principal.Tenant, _ = claims[v.opts.TenantClaim].(string)
+	}
+	for _, mapping := range v.opts.ClaimMappings {
+		if slices.Contains(claimValues(claims[mapping.Claim]), mapping.Value) {
+			principal.Scopes = appendUnique(principal.Scopes, mapping.Scopes...)
+			principal.Roles = appendUnique(principal.Roles, mapping.Roles...)
+		}
+	}
+	return principal, nil
+}
+
+func appendUnique(values []string, more ...string) []string {
+	for _, value := range more {
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// claimValues flattens a string, space separated string or list claim.
+func claimValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case bool:
+		if v {
+			return []string{"true"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://idp.example.com/"
+	testAudience = "cruder"
+)
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+func newSigningKey(t *testing.T, kid string, method jwt.SigningMethod) signingKey {
+	t.Helper()
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch method {
+	case jwt.SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return signingKey{kid: kid, method: method, private: private}
+}
+
+func (k signingKey) jwk() map[string]string {
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig", "n": b64(public.N.Bytes()), "e": b64(big.NewInt(int64(public.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256", "x": b64(public.X.FillBytes(make([]byte, 32))), "y": b64(public.Y.FillBytes(make([]byte, 32)))}
+	default:
+		return map[string]string{"kty": "OKP", "kid": k.kid, "crv": "Ed25519", "x": b64(public.(ed25519.PublicKey))}
+	}
+}
+
+func (k signingKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+// jwksServer stands in for the identity provider's JWKS endpoint.
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	keys     []signingKey
+	requests int
+}
+
+func newJWKSServer(t *testing.T, keys ...signingKey) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		set := make([]map[string]string, 0, len(s.keys))
+		for _, key := range s.keys {
+			set = append(set, key.jwk())
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": set})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...signingKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func newTestVerifier(t *testing.T, url string, opts VerifierOptions) *Verifier {
+	t.Helper()
+	keys, err := NewKeySet(context.Background(), KeySetOptions{URL: url, MinRefreshInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	t.Cleanup(func() { keys.Close() })
+	opts.Issuer, opts.Audience = testIssuer, testAudience
+	return NewVerifier(keys, opts)
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-123",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "users:read users:write",
+	}
+}
+
+func TestVerify_SigningMethods(t *testing.T) {
+	keys := []signingKey{
+		newSigningKey(t, "rsa", jwt.SigningMethodRS256),
+		newSigningKey(t, "ec", jwt.SigningMethodES256),
+		newSigningKey(t, "ed", jwt.SigningMethodEdDSA),
+	}
+	server := newJWKSServer(t, keys...)
+	verifier := newTestVerifier(t, server.URL, VerifierOptions{})
+
+	for _, key := range keys {
+		principal, err := verifier.Verify(context.Background(), key.sign(t, validClaims()))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", key.method.Alg(), err)
+		}
+		if principal.Subject != "user-123" || principal.Method != model.AuthMethodJWT {
+			t.Errorf("%s: unexpected principal %+v", key.method.Alg(), principal)
+		}
+		if !principal.HasScope(model.ScopeUsersWrite) || principal.HasScope(model.ScopeUsersDelete) {
+			t.Errorf("%s: unexpected scopes %v", key.method.Alg(), principal.Scopes)
+		}
+	}
+}
+
+func TestVerify_RejectsInvalidTokens(t *testing.T) {
+	key := newSigningKey(t, "rsa", jwt.SigningMethodRS256)
+	other := newSigningKey(t, "rsa", jwt.SigningMethodRS256)
+	server := newJWKSServer(t, key)
+	verifier := newTestVerifier(t, server.URL, VerifierOptions{})
+
+	with := func(name string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+
+	cases := map[string]string{
+		"wrong audience":  key.sign(t, with("aud", "someone-else")),
+		"wrong issuer":    key.sign(t, with("iss", "https://evil.example.com/")),
+		"expired":         key.sign(t, with("exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiry":       key.sign(t, with("exp", nil)),
+		"no subject":      key.sign(t, with("sub", nil)),
+		"wrong signature": other.sign(t, validClaims()),
+		"hmac":            hmac,
+		"garbage":         "not.a.token",
+	}
+	for name, token := range cases {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, model.ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestVerify_PicksUpRotatedKeys(t *testing.T) {
+	oldKey := newSigningKey(t, "2025-01", jwt.SigningMethodES256)
+	newKey := newSigningKey(t, "2025-02", jwt.SigningMethodES256)
+	server := newJWKSServer(t, oldKey)
+	verifier := newTestVerifier(t, server.URL, VerifierOptions{})
+
+	if _, err := verifier.Verify(context.Background(), oldKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), oldKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if server.requests != 1 {
+		t.Errorf("expected known keys to be served from the cache, got %d requests", server.requests)
+	}
+
+	server.setKeys(newKey)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := verifier.Verify(context.Background(), newKey.sign(t, validClaims())); err != nil {
+		t.Fatalf("expected the new key to be fetched, got %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), oldKey.sign(t, validClaims())); !errors.Is(err, model.ErrInvalidToken) {
+		t.Errorf("expected the retired key to be rejected, got %v", err)
+	}
+}
+
+func TestVerify_ClaimMappings(t *testing.T) {
+	key := newSigningKey(t, "ed", jwt.SigningMethodEdDSA)
+	server := newJWKSServer(t, key)
+	verifier := newTestVerifier(t, server.URL, VerifierOptions{
+		ScopeClaim: "scp",
+		ClaimMappings: []ClaimMapping{
+			{Claim: "groups", Value: "cruder-admins", Scopes: []string{model.ScopeUsersDelete}},
+		},
+	})
+
+	claims := validClaims()
+	claims["scp"] = []string{model.ScopeUsersRead}
+	claims["groups"] = []string{"staff", "cruder-admins"}
+
+	principal, err := verifier.Verify(context.Background(), key.sign(t, claims))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !principal.HasScope(model.ScopeUsersRead) || !principal.HasScope(model.ScopeUsersDelete) || principal.HasScope(model.ScopeUsersWrite) {
+		t.Errorf("unexpected scopes %v", principal.Scopes)
+	}
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkStderr = "stderr"
+	SinkFile   = "file"
+	SinkSyslog = "syslog"
+)
+
+type AccessOptions struct {
+	Options
+
+	Sinks      []string
+	File       FileOptions
+	Syslog     SyslogOptions
+	BufferSize int
+	// OnDrop is called for every entry discarded because the buffer is full.
+	OnDrop func()
+}
+
+// FileOptions configure the rotated access log file. MaxAge is rounded up to
+// whole days, the unit lumberjack keeps; zero keeps old files regardless of
+// age.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxAge     time.Duration
+	MaxBackups int
+	Compress   bool
+}
+
+type SyslogOptions struct {
+	// Network and Address are empty for the local syslog socket.
+	Network string
+	Address string
+	Tag     string
+}
+
+// NewAccess builds the access logger. Entries are written to every sink
+// through an AsyncWriter, so a slow sink never stalls request handling; the
+// returned closer flushes the buffer and closes the sinks.
+func NewAccess(opts AccessOptions) (*slog.Logger, io.Closer, error) {
+	if len(opts.Sinks) == 0 {
+		opts.Sinks = []string{SinkStdout}
+	}
+
+	var writers []io.Writer
+	var closers sinkClosers
+	for _, sink := range opts.Sinks {
+		switch sink {
+		case SinkStdout:
+			writers = append(writers, os.Stdout)
+		case SinkStderr:
+			writers = append(writers, os.Stderr)
+		case SinkFile:
+			if opts.File.Path == "" {
+				closers.Close()
+				return nil, nil, errors.New("access log file sink requires a path")
+			}
+			file := &lumberjack.Logger{
+				Filename:   opts.File.Path,
+				MaxSize:    opts.File.MaxSizeMB,
+				MaxAge:     maxAgeDays(opts.File.MaxAge),
+				MaxBackups: opts.File.MaxBackups,
+				Compress:   opts.File.Compress,
+			}
+			writers = append(writers, file)
+			closers = append(closers, file)
+		case SinkSyslog:
+			w, err := openSyslog(opts.Syslog)
+			if err != nil {
+				closers.Close()
+				return nil, nil, err
+			}
+			writers = append(writers, w)
+			closers = append(closers, w)
+		default:
+			closers.Close()
+			return nil, nil, fmt.Errorf("unknown access log sink %q", sink)
+		}
+	}
+
+	async := NewAsyncWriter(fanout(writers), opts.BufferSize, opts.OnDrop)
+	logger, err := newLogger(async, opts.Options)
+	if err != nil {
+		async.Close()
+		closers.Close()
+		return nil, nil, err
+	}
+	return logger, append(sinkClosers{async}, closers...), nil
+}
+
+// sinkClosers closes in order, so the async writer is drained before the
+// sinks it writes to are closed.
+type sinkClosers []io.Closer
+
+func (c sinkClosers) Close() error {
+	var errs []error
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// fanout writes to every sink even when one of them fails, unlike
+// io.MultiWriter which stops at the first error.
+type fanout []io.Writer
+
+func (f fanout) Write(p []byte) (int, error) {
+	var errs []error
+	for _, w := range f {
+		if _, err := w.Write(p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+func maxAgeDays(d time.Duration) int {
+	const day = 24 * time.Hour
+	if d <= 0 {
+		return 0
+	}
+	return int((d + day - 1) / day)
+}
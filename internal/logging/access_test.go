@@ -0,0 +1,26 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxAgeDays(t *testing.T) {
+	tests := []struct {
+		maxAge time.Duration
+		want   int
+	}{
+		{maxAge: 0, want: 0},
+		{maxAge: -time.Hour, want: 0},
+		{maxAge: time.Hour, want: 1},
+		{maxAge: 24 * time.Hour, want: 1},
+		{maxAge: 25 * time.Hour, want: 2},
+		{maxAge: 168 * time.Hour, want: 7},
+	}
+
+	for _, tt := range tests {
+		if got := maxAgeDays(tt.maxAge); got != tt.want {
+			t.Errorf("maxAgeDays(%v) = %d, want %d", tt.maxAge, got, tt.want)
+		}
+	}
+}
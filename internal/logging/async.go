@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncWriter hands writes to a background goroutine through a bounded
+// buffer. When the buffer is full the write is dropped and counted instead of
+// blocking the caller.
+type AsyncWriter struct {
+	out     io.Writer
+	entries chan []byte
+	onDrop  func()
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func NewAsyncWriter(out io.Writer, size int, onDrop func()) *AsyncWriter {
+	if size <= 0 {
+		size = 1024
+	}
+	w := &AsyncWriter{
+		out:     out,
+		entries: make(chan []byte, size),
+		onDrop:  onDrop,
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.drop()
+		return len(p), nil
+	}
+
+	select {
+	case w.entries <- entry:
+	default:
+		w.drop()
+	}
+	return len(p), nil
+}
+
+func (w *AsyncWriter) drop() {
+	w.dropped.Add(1)
+	if w.onDrop != nil {
+		w.onDrop()
+	}
+}
+
+// Dropped returns the number of entries discarded so far.
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	for entry := range w.entries {
+		w.out.Write(entry)
+	}
+}
+
+// Close stops accepting writes and blocks until every buffered entry has been
+// written.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriter_DropsWhenFull(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	drops := 0
+	w := NewAsyncWriter(out, 2, func() { drops++ })
+
+	// One entry is held by the blocked writer goroutine at most, two fit in
+	// the buffer and the rest must be dropped without blocking.
+	for i := 0; i < 10; i++ {
+		w.Write([]byte("entry\n"))
+	}
+
+	if w.Dropped() < 7 {
+		t.Errorf("expected at least 7 dropped entries, got %d", w.Dropped())
+	}
+	if uint64(drops) != w.Dropped() {
+		t.Errorf("expected OnDrop to be called %d times, got %d", w.Dropped(), drops)
+	}
+
+	close(out.release)
+	w.Close()
+
+	written := strings.Count(out.buf.String(), "entry")
+	if uint64(written)+w.Dropped() != 10 {
+		t.Errorf("expected written and dropped entries to add up to 10, got %d and %d", written, w.Dropped())
+	}
+}
+
+func TestAsyncWriter_CloseFlushes(t *testing.T) {
+	var out bytes.Buffer
+	w := NewAsyncWriter(&out, 100, nil)
+
+	for i := 0; i < 50; i++ {
+		w.Write([]byte("entry\n"))
+	}
+	w.Close()
+
+	if got := strings.Count(out.String(), "entry"); got != 50 {
+		t.Errorf("expected 50 entries after close, got %d", got)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("expected no dropped entries, got %d", w.Dropped())
+	}
+}
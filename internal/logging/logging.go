@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Options struct {
+	Level  string
+	Format string
+	Output string
+
+	// ReplaceAttr, when set, is applied to every attribute including the
+	// message, and is where redaction of sensitive values happens.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// New builds the application logger. The returned closer releases the output
+// file, if one was opened, and is a no-op for stdout and stderr.
+func New(opts Options) (*slog.Logger, io.Closer, error) {
+	out, closer, err := openOutput(opts.Output)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger, err := newLogger(out, opts)
+	if err != nil {
+		closer.Close()
+		return nil, nil, err
+	}
+	return logger, closer, nil
+}
+
+func newLogger(out io.Writer, opts Options) (*slog.Logger, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, ReplaceAttr: opts.ReplaceAttr}
+
+	var handler slog.Handler
+	switch opts.Format {
+	case "", "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	case "text":
+		handler = slog.NewTextHandler(out, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+func ParseLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if value == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.ToUpper(value))); err != nil {
+		return level, fmt.Errorf("unknown log level %q", value)
+	}
+	return level, nil
+}
+
+func openOutput(output string) (io.Writer, io.Closer, error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, nopCloser{}, nil
+	case "stderr":
+		return os.Stderr, nopCloser{}, nil
+	default:
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log output: %w", err)
+		}
+		return f, f, nil
+	}
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// contextHandler adds the request and trace identifiers carried by the
+// context to every record, so callers only need to use the *Context methods.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	hasRequestID, hasTraceID := false, false
+	record.Attrs(func(attr slog.Attr) bool {
+		hasRequestID = hasRequestID || attr.Key == "request_id"
+		hasTraceID = hasTraceID || attr.Key == "trace_id"
+		return true
+	})
+
+	if requestID := RequestID(ctx); requestID != "" && !hasRequestID {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() && !hasTraceID {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
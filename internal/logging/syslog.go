@@ -0,0 +1,17 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+func openSyslog(opts SyslogOptions) (io.WriteCloser, error) {
+	w, err := syslog.Dial(opts.Network, opts.Address, syslog.LOG_INFO|syslog.LOG_LOCAL0, opts.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return w, nil
+}
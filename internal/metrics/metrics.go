@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cruder"
+
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests processed, by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	queryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Repository method latency, by repository, method and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method", "outcome"})
+
+	usersCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_created_total",
+		Help:      "Users created.",
+	})
+
+	usersUpdated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_updated_total",
+		Help:      "Users updated.",
+	})
+
+	usersDeleted = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "users_deleted_total",
+		Help:      "Users deleted.",
+	})
+
+	accessLogDropped = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "access_log_dropped_total",
+		Help:      "Access log entries dropped because the log buffer was full.",
+	})
+
+	validationFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_failures_total",
+		Help:      "Rejected requests, by validation rule.",
+	}, []string{"rule"})
+
+	authFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Failed authentications, by scheme.",
+	}, []string{"scheme"})
+
+	authBans = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_bans_total",
+		Help:      "Temporary authentication bans, by kind of client identifier.",
+	}, []string{"kind"})
+
+	rateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by the rate limiter, by the limit that was exceeded.",
+	}, []string{"scope"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB exposes sql.DBStats for a connection pool under the given name.
+func RegisterDB(name string, db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+func ObserveHTTPRequest(route, method, status string, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, status).Inc()
+	httpDuration.WithLabelValues(route, method, status).Observe(duration.Seconds())
+}
+
+func ObserveQuery(repository, method string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	queryDuration.WithLabelValues(repository, method, outcome).Observe(time.Since(start).Seconds())
+}
+
+func UserCreated() {
+	usersCreated.Inc()
+}
+
+func UserUpdated() {
+	usersUpdated.Inc()
+}
+
+func UserDeleted() {
+	usersDeleted.Inc()
+}
+
+func ValidationFailed(rule string) {
+	validationFailures.WithLabelValues(rule).Inc()
+}
+
+func AccessLogDropped() {
+	accessLogDropped.Inc()
+}
+
+func RateLimited(scope string) {
+	rateLimited.WithLabelValues(scope).Inc()
+}
+
+func AuthFailure(scheme string) {
+	authFailures.WithLabelValues(scheme).Inc()
+}
+
+func AuthBan(kind string) {
+	authBans.WithLabelValues(kind).Inc()
+}
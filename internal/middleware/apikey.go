@@ -1,31 +1,22 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+
+	"cruder/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
 
-func APIKeyMiddleware(validAPIKey string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if validAPIKey == "" {
-			c.Next()
-			return
-		}
-
-		apiKey := c.GetHeader("X-API-Key")
-		if apiKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing X-API-Key header"})
-			c.Abort()
-			return
-		}
+// APIKeyKey holds the *model.APIKey the request was authenticated with.
+const APIKeyKey = "api_key"
 
-		if apiKey != validAPIKey {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid X-API-Key"})
-			c.Abort()
-			return
-		}
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error)
+}
 
-		c.Next()
-	}
+// APIKeyMiddleware authenticates the X-API-Key header only. A nil
+// authenticator disables authentication.
+func APIKeyMiddleware(auth APIKeyAuthenticator) gin.HandlerFunc {
+	return AuthMiddleware(AuthOptions{APIKeys: auth}, SchemeAPIKey)
 }
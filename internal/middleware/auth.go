@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"cruder/internal/audit"
+	"cruder/internal/metrics"
+	"cruder/internal/model"
+	"cruder/pkg/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CallerKey is the gin context key holding the identity of the
+	// authenticated caller.
+	CallerKey = "caller"
+	// PrincipalKey holds the *model.Principal of the authenticated caller.
+	PrincipalKey = "principal"
+)
+
+const (
+	SchemeAPIKey     = "api_key"
+	SchemeBearer     = "bearer"
+	SchemeClientCert = "client_cert"
+	SchemeSignature  = "signature"
+)
+
+type TokenVerifier interface {
+	Verify(ctx context.Context, rawToken string) (*model.Principal, error)
+}
+
+// AuthGuard throttles API key guessing by client address and key prefix.
+type AuthGuard interface {
+	Check(ip, prefix string) (time.Duration, bool)
+	Failure(ctx context.Context, ip, prefix string) time.Duration
+}
+
+// ClientCertAuthenticator maps a client certificate, already verified by
+// the TLS handshake, to a principal.
+type ClientCertAuthenticator interface {
+	Authenticate(ctx context.Context, cert *x509.Certificate) (*model.Principal, error)
+}
+
+// SignatureVerifier checks an HMAC-signed request and returns the
+// principal of the signing key.
+type SignatureVerifier interface {
+	Verify(ctx context.Context, r *http.Request) (*model.Principal, error)
+}
+
+type AuthOptions struct {
+	APIKeys     APIKeyAuthenticator
+	Tokens      TokenVerifier
+	ClientCerts ClientCertAuthenticator
+	Signatures  SignatureVerifier
+	Guard       AuthGuard
+}
+
+// AuthMiddleware authenticates the request with one of the given schemes.
+// Schemes whose authenticator is nil are ignored, and when none is left the
+// route is open, matching how authentication is disabled elsewhere. Headers
+// take precedence over a client certificate, so a service on a mutual TLS
+// connection can still act with another credential.
+func AuthMiddleware(opts AuthOptions, schemes ...string) gin.HandlerFunc {
+	apiKeys := opts.APIKeys != nil && slices.Contains(schemes, SchemeAPIKey)
+	bearer := opts.Tokens != nil && slices.Contains(schemes, SchemeBearer)
+	clientCerts := opts.ClientCerts != nil && slices.Contains(schemes, SchemeClientCert)
+	signatures := opts.Signatures != nil && slices.Contains(schemes, SchemeSignature)
+
+	return func(c *gin.Context) {
+		if !apiKeys && !bearer && !clientCerts && !signatures {
+			c.Next()
+			return
+		}
+
+		if signatures && signing.IsSigned(c.GetHeader("Authorization")) {
+			principal, err := opts.Signatures.Verify(c.Request.Context(), c.Request)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				switch {
+				case errors.Is(err, model.ErrInvalidSignature):
+					metrics.AuthFailure(SchemeSignature)
+					c.Header("WWW-Authenticate", signing.Scheme)
+					c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				case errors.As(err, &tooLarge):
+					c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+				default:
+					c.Error(err)
+					c.JSON(http.StatusServiceUnavailable, gin.H{"error": "authentication unavailable"})
+				}
+				c.Abort()
+				return
+			}
+			setPrincipal(c, principal)
+			c.Next()
+			return
+		}
+
+		if bearer {
+			if token, ok := bearerToken(c.GetHeader("Authorization")); ok {
+				principal, err := opts.Tokens.Verify(c.Request.Context(), token)
+				if err != nil {
+					if errors.Is(err, model.ErrInvalidToken) {
+						metrics.AuthFailure(SchemeBearer)
+						c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+						c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid bearer token"})
+					} else {
+						c.Error(err)
+						c.JSON(http.StatusServiceUnavailable, gin.H{"error": "authentication unavailable"})
+					}
+					c.Abort()
+					return
+				}
+				setPrincipal(c, principal)
+				c.Next()
+				return
+			}
+		}
+
+		if apiKeys {
+			if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+				// Only the address ban applies up front. Key prefixes are not
+				// secret, so banning them before authenticating would let
+				// anyone lock out the genuine key; a banned prefix only
+				// changes how failures with it are answered.
+				prefix, _ := model.APIKeyPrefix(rawKey)
+				if opts.Guard != nil {
+					if retryAfter, banned := opts.Guard.Check(c.ClientIP(), ""); banned {
+						authBanned(c, retryAfter)
+						return
+					}
+				}
+				key, err := opts.APIKeys.Authenticate(c.Request.Context(), rawKey)
+				if err != nil {
+					if errors.Is(err, model.ErrInvalidAPIKey) || errors.Is(err, model.ErrExpiredAPIKey) || errors.Is(err, model.ErrRevokedAPIKey) {
+						metrics.AuthFailure(SchemeAPIKey)
+						if opts.Guard != nil {
+							delay := opts.Guard.Failure(c.Request.Context(), c.ClientIP(), prefix)
+							if retryAfter, banned := opts.Guard.Check(c.ClientIP(), prefix); banned {
+								authBanned(c, retryAfter)
+								return
+							}
+							wait(c.Request.Context(), delay)
+						}
+						c.JSON(http.StatusForbidden, gin.H{"error": "invalid X-API-Key"})
+					} else {
+						c.Error(err)
+						c.JSON(http.StatusServiceUnavailable, gin.H{"error": "authentication unavailable"})
+					}
+					c.Abort()
+					return
+				}
+				c.Set(APIKeyKey, key)
+				setPrincipal(c, &model.Principal{Subject: key.Name, Method: model.AuthMethodAPIKey, Scopes: key.Scopes, Tenant: key.Tenant})
+				c.Next()
+				return
+			}
+		}
+
+		if clientCerts {
+			if cert, ok := clientCertificate(c.Request); ok {
+				principal, err := opts.ClientCerts.Authenticate(c.Request.Context(), cert)
+				if err != nil {
+					metrics.AuthFailure(SchemeClientCert)
+					c.JSON(http.StatusForbidden, gin.H{"error": "client certificate is not authorized"})
+					c.Abort()
+					return
+				}
+				setPrincipal(c, principal)
+				c.Next()
+				return
+			}
+		}
+
+		if bearer {
+			c.Header("WWW-Authenticate", "Bearer")
+		}
+		switch {
+		case !apiKeys && !bearer && !signatures:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing client certificate"})
+		case !apiKeys && !bearer:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing signed Authorization header"})
+		case apiKeys && bearer:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing X-API-Key or Authorization header"})
+		case bearer:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing X-API-Key header"})
+		}
+		c.Abort()
+	}
+}
+
+// clientCertificate returns the leaf of a verified client certificate chain.
+// Certificates the handshake did not verify are ignored.
+func clientCertificate(r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return r.TLS.VerifiedChains[0][0], true
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func authBanned(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", ceilSeconds(retryAfter))
+	abortWithProblem(c, http.StatusTooManyRequests, "too many failed authentication attempts, retry after "+ceilSeconds(retryAfter)+"s")
+}
+
+// wait delays a rejection, returning early when the client goes away.
+func wait(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+func setPrincipal(c *gin.Context, principal *model.Principal) {
+	c.Set(CallerKey, principal.Subject)
+	c.Set(PrincipalKey, principal)
+	c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), principal.Method+":"+principal.Subject))
+}
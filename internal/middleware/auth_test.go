@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cruder/internal/bruteforce"
+	"cruder/internal/certauth"
+	"cruder/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	genuineKey = "crd_0123456789ab_genuine"
+	guessedKey = "crd_0123456789ab_guessed"
+)
+
+// staticKeys accepts the raw keys it holds and rejects every other one.
+type staticKeys map[string]*model.APIKey
+
+func (s staticKeys) Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error) {
+	if key, ok := s[rawKey]; ok {
+		return key, nil
+	}
+	return nil, model.ErrInvalidAPIKey
+}
+
+// unavailableKeys fails as if the key store could not be reached.
+type unavailableKeys struct{}
+
+func (unavailableKeys) Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error) {
+	return nil, errors.New("connection refused")
+}
+
+// fakeTokens accepts the bearer token "good" as alice.
+type fakeTokens struct{ err error }
+
+func (f fakeTokens) Verify(ctx context.Context, rawToken string) (*model.Principal, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if rawToken != "good" {
+		return nil, model.ErrInvalidToken
+	}
+	return &model.Principal{Subject: "alice", Method: model.AuthMethodJWT}, nil
+}
+
+// fakeSignatures accepts every signed request whose body fits in 16 bytes.
+type fakeSignatures struct{ err error }
+
+func (f fakeSignatures) Verify(ctx context.Context, r *http.Request) (*model.Principal, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if _, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, 16)); err != nil {
+		return nil, err
+	}
+	return &model.Principal{Subject: "signer", Method: model.AuthMethodSignature}, nil
+}
+
+func clientCert(commonName string) *tls.ConnectionState {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+}
+
+func authRouter(opts AuthOptions, schemes ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Any("/users/", AuthMiddleware(opts, schemes...), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(CallerKey))
+	})
+	return router
+}
+
+func authRequest(router *gin.Engine, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddleware_GuardBans(t *testing.T) {
+	guard := bruteforce.NewGuard(slog.New(slog.DiscardHandler), bruteforce.Options{BanAfter: 3, BanDuration: time.Minute})
+	router := authRouter(AuthOptions{
+		APIKeys: staticKeys{genuineKey: {Name: "billing"}},
+		Guard:   guard,
+	}, SchemeAPIKey)
+
+	// Guesses for one prefix from many addresses ban the prefix; the
+	// attempt that trips the ban is already answered with 429.
+	for i, want := range []int{http.StatusForbidden, http.StatusForbidden, http.StatusTooManyRequests} {
+		w := authRequest(router, fmt.Sprintf("203.0.113.%d:1234", i+1), map[string]string{"X-API-Key": guessedKey})
+		if w.Code != want {
+			t.Errorf("guess %d: expected %d, got %d", i, want, w.Code)
+		}
+	}
+	if w := authRequest(router, "203.0.113.9:1234", map[string]string{"X-API-Key": guessedKey}); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected further guesses for the banned prefix to get 429 with Retry-After, got %d", w.Code)
+	}
+
+	if w := authRequest(router, "198.51.100.1:1234", map[string]string{"X-API-Key": genuineKey}); w.Code != http.StatusOK || w.Body.String() != "billing" {
+		t.Errorf("expected the genuine key to keep working under a prefix ban, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthMiddleware_GuardBansAddressBeforeAuthenticating(t *testing.T) {
+	guard := bruteforce.NewGuard(slog.New(slog.DiscardHandler), bruteforce.Options{BanAfter: 2, BanDuration: time.Minute})
+	router := authRouter(AuthOptions{
+		APIKeys: staticKeys{genuineKey: {Name: "billing"}},
+		Guard:   guard,
+	}, SchemeAPIKey)
+
+	for _, key := range []string{"crd_aaaaaaaaaaaa_x", "crd_bbbbbbbbbbbb_x"} {
+		authRequest(router, "203.0.113.1:1234", map[string]string{"X-API-Key": key})
+	}
+	if w := authRequest(router, "203.0.113.1:1234", map[string]string{"X-API-Key": genuineKey}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the banned address to be refused before authenticating, got %d", w.Code)
+	}
+	if w := authRequest(router, "203.0.113.2:1234", map[string]string{"X-API-Key": genuineKey}); w.Code != http.StatusOK {
+		t.Errorf("expected other addresses to be unaffected, got %d", w.Code)
+	}
+}
+
+var allSchemes = []string{SchemeSignature, SchemeBearer, SchemeAPIKey, SchemeClientCert}
+
+func allAuthOptions() AuthOptions {
+	return AuthOptions{
+		APIKeys:     staticKeys{genuineKey: {Name: "billing", Tenant: "acme"}},
+		Tokens:      fakeTokens{},
+		ClientCerts: certauth.NewAuthenticator([]certauth.Identity{{Subject: "svc-reports"}}),
+		Signatures:  fakeSignatures{},
+	}
+}
+
+func TestAuthMiddleware_SchemePrecedence(t *testing.T) {
+	router := authRouter(allAuthOptions(), allSchemes...)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		tls     *tls.ConnectionState
+		caller  string
+	}{
+		{
+			name:    "signature over everything",
+			headers: map[string]string{"Authorization": "HMAC-SHA256 keyId=signer", "X-API-Key": genuineKey},
+			tls:     clientCert("svc-reports"),
+			caller:  "signer",
+		},
+		{
+			name:    "bearer over api key and certificate",
+			headers: map[string]string{"Authorization": "Bearer good", "X-API-Key": genuineKey},
+			tls:     clientCert("svc-reports"),
+			caller:  "alice",
+		},
+		{
+			name:    "api key over certificate",
+			headers: map[string]string{"X-API-Key": genuineKey},
+			tls:     clientCert("svc-reports"),
+			caller:  "billing",
+		},
+		{
+			name:   "certificate alone",
+			tls:    clientCert("svc-reports"),
+			caller: "svc-reports",
+		},
+		{
+			name:    "unverified certificate is ignored",
+			headers: map[string]string{"X-API-Key": genuineKey},
+			tls:     &tls.ConnectionState{},
+			caller:  "billing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+			req.TLS = tt.tls
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK || w.Body.String() != tt.caller {
+				t.Errorf("expected 200 as %s, got %d %s", tt.caller, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_Failures(t *testing.T) {
+	unavailable := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		opts      func(*AuthOptions)
+		schemes   []string
+		headers   map[string]string
+		body      string
+		tls       *tls.ConnectionState
+		status    int
+		challenge string
+	}{
+		{
+			name:      "invalid bearer token",
+			headers:   map[string]string{"Authorization": "Bearer forged"},
+			status:    http.StatusUnauthorized,
+			challenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:    "token verifier unavailable",
+			opts:    func(o *AuthOptions) { o.Tokens = fakeTokens{err: unavailable} },
+			headers: map[string]string{"Authorization": "Bearer good"},
+			status:  http.StatusServiceUnavailable,
+		},
+		{
+			name:    "invalid api key",
+			headers: map[string]string{"X-API-Key": guessedKey},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:    "api key store unavailable",
+			opts:    func(o *AuthOptions) { o.APIKeys = unavailableKeys{} },
+			headers: map[string]string{"X-API-Key": genuineKey},
+			status:  http.StatusServiceUnavailable,
+		},
+		{
+			name:      "invalid signature",
+			opts:      func(o *AuthOptions) { o.Signatures = fakeSignatures{err: model.ErrInvalidSignature} },
+			headers:   map[string]string{"Authorization": "HMAC-SHA256 keyId=signer"},
+			status:    http.StatusUnauthorized,
+			challenge: "HMAC-SHA256",
+		},
+		{
+			name:    "signature verifier unavailable",
+			opts:    func(o *AuthOptions) { o.Signatures = fakeSignatures{err: unavailable} },
+			headers: map[string]string{"Authorization": "HMAC-SHA256 keyId=signer"},
+			status:  http.StatusServiceUnavailable,
+		},
+		{
+			name:    "signed body too large",
+			headers: map[string]string{"Authorization": "HMAC-SHA256 keyId=signer"},
+			body:    strings.Repeat("x", 32),
+			status:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "unknown client certificate",
+			tls:    clientCert("svc-unknown"),
+			status: http.StatusForbidden,
+		},
+		{
+			name:      "no credentials",
+			status:    http.StatusUnauthorized,
+			challenge: "Bearer",
+		},
+		{
+			name:    "no client certificate",
+			schemes: []string{SchemeClientCert},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "no schemes configured",
+			opts:    func(o *AuthOptions) { *o = AuthOptions{} },
+			headers: map[string]string{"X-API-Key": guessedKey},
+			status:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := allAuthOptions()
+			if tt.opts != nil {
+				tt.opts(&opts)
+			}
+			schemes := tt.schemes
+			if schemes == nil {
+				schemes = allSchemes
+			}
+			router := authRouter(opts, schemes...)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/", strings.NewReader(tt.body))
+			req.TLS = tt.tls
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d %s", tt.status, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("expected WWW-Authenticate %q, got %q", tt.challenge, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"cruder/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxInspectedBody bounds how much of a request body AuthorizeFields reads.
+const maxInspectedBody = 1 << 20
+
+type Authorizer interface {
+	Authorize(ctx context.Context, principal *model.Principal, permission string, self bool) error
+	AuthorizeField(ctx context.Context, principal *model.Principal, resource, field string, self bool) error
+}
+
+// RequireScope rejects requests whose credentials lack scope. It lets
+// requests through when authentication is disabled.
+func RequireScope(scope string) gin.HandlerFunc {
+	return Authorize(nil, scope)
+}
+
+// Authorize requires both that the credential carries permission as a scope
+// and, when authz is set, that the caller's roles grant it. Requests without
+// a principal pass, since authentication is disabled for them.
+func Authorize(authz Authorizer, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := currentPrincipal(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if !principal.HasScope(permission) {
+			abortWithProblem(c, http.StatusForbidden, "credentials lack scope "+permission)
+			return
+		}
+		if authz != nil {
+			if err := authz.Authorize(c.Request.Context(), principal, permission, isSelf(c, principal)); err != nil {
+				denied(c, err)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// AuthorizeFields checks every field set in a JSON request body against the
+// field rules for resource. Empty strings and nulls leave a field unchanged
+// and are not checked. Keys are folded the way encoding/json matches them to
+// struct fields, so "USERNAME" is checked as username, and a body naming a
+// field twice in different cases is rejected as ambiguous. The body is
+// restored for the handler.
+func AuthorizeFields(authz Authorizer, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := currentPrincipal(c)
+		if authz == nil || !ok || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxInspectedBody+1))
+		c.Request.Body.Close()
+		if err != nil || len(body) > maxInspectedBody {
+			abortWithProblem(c, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			// Malformed bodies are the handler's to reject.
+			c.Next()
+			return
+		}
+		// Duplicates are rejected before any field is checked, so the answer
+		// does not depend on the order the keys are visited in.
+		folded := make(map[string]json.RawMessage, len(fields))
+		for key, value := range fields {
+			field := foldField(key)
+			if _, ok := folded[field]; ok {
+				abortWithProblem(c, http.StatusBadRequest, "field "+field+" is set more than once")
+				return
+			}
+			folded[field] = value
+		}
+		self := isSelf(c, principal)
+		for field, value := range folded {
+			if v := string(value); v == `""` || v == "null" {
+				continue
+			}
+			if err := authz.AuthorizeField(c.Request.Context(), principal, resource, field, self); err != nil {
+				denied(c, err)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// foldField maps a JSON key to the lower-case field name encoding/json would
+// bind it to, folding each rune to the smallest of its case-folding set as
+// encoding/json does, so "ſ" and "K" (Kelvin) match s and k.
+func foldField(key string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		for {
+			folded := unicode.SimpleFold(r)
+			if folded <= r {
+				return folded
+			}
+			r = folded
+		}
+	}, key))
+}
+
+func currentPrincipal(c *gin.Context) (*model.Principal, bool) {
+	value, ok := c.Get(PrincipalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*model.Principal)
+	return principal, ok
+}
+
+// isSelf reports whether the route addresses the caller's own record, by
+// uuid or username. Only tokens from the identity provider name a user; API
+// key names, certificate subjects and signing key IDs are chosen by
+// operators and may collide with a username.
+func isSelf(c *gin.Context, principal *model.Principal) bool {
+	if principal.Method != model.AuthMethodJWT {
+		return false
+	}
+	for _, param := range []string{"uuid", "username"} {
+		if value := c.Param(param); value != "" && value == principal.Subject {
+			return true
+		}
+	}
+	return false
+}
+
+func denied(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrPermissionDenied) {
+		abortWithProblem(c, http.StatusForbidden, err.Error())
+		return
+	}
+	c.Error(err)
+	abortWithProblem(c, http.StatusInternalServerError, "authorization failed")
+}
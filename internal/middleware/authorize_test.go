@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"cruder/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleAuthorizer grants permissions by role, adds "self" when the request
+// targets the caller, and restricts fields to the roles listed for them.
+type roleAuthorizer struct {
+	permissions map[string][]string
+	fields      map[string][]string
+}
+
+func (a roleAuthorizer) roles(principal *model.Principal, self bool) []string {
+	roles := principal.Roles
+	if self {
+		roles = append(slices.Clone(roles), "self")
+	}
+	return roles
+}
+
+func (a roleAuthorizer) Authorize(ctx context.Context, principal *model.Principal, permission string, self bool) error {
+	for _, role := range a.roles(principal, self) {
+		if slices.Contains(a.permissions[role], permission) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", model.ErrPermissionDenied, permission)
+}
+
+func (a roleAuthorizer) AuthorizeField(ctx context.Context, principal *model.Principal, resource, field string, self bool) error {
+	allowed, ok := a.fields[resource+"."+field]
+	if !ok {
+		return nil
+	}
+	for _, role := range a.roles(principal, self) {
+		if slices.Contains(allowed, role) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", model.ErrPermissionDenied, field)
+}
+
+var testAuthorizer = roleAuthorizer{
+	permissions: map[string][]string{
+		"editor": {model.ScopeUsersRead, model.ScopeUsersWrite},
+		"admin":  {model.ScopeUsersRead, model.ScopeUsersWrite, model.ScopeUsersDelete},
+		"self":   {model.ScopeUsersRead, model.ScopeUsersWrite},
+	},
+	fields: map[string][]string{"users.username": {"admin"}},
+}
+
+func serveAs(principal *model.Principal, handlers ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if principal != nil {
+			c.Set(PrincipalKey, principal)
+		}
+	})
+	handlers = append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.PATCH("/users/:uuid", handlers...)
+	return router
+}
+
+func TestAuthorize(t *testing.T) {
+	allScopes := []string{model.ScopeUsersRead, model.ScopeUsersWrite, model.ScopeUsersDelete}
+	cases := []struct {
+		name       string
+		principal  *model.Principal
+		permission string
+		status     int
+	}{
+		{name: "no principal", permission: model.ScopeUsersWrite, status: http.StatusOK},
+		{name: "role grants", principal: &model.Principal{Subject: "bob", Method: model.AuthMethodJWT, Scopes: allScopes, Roles: []string{"editor"}}, permission: model.ScopeUsersWrite, status: http.StatusOK},
+		{name: "missing scope", principal: &model.Principal{Subject: "bob", Method: model.AuthMethodJWT, Scopes: []string{model.ScopeUsersRead}, Roles: []string{"admin"}}, permission: model.ScopeUsersWrite, status: http.StatusForbidden},
+		{name: "role denies", principal: &model.Principal{Subject: "bob", Method: model.AuthMethodJWT, Scopes: allScopes, Roles: []string{"editor"}}, permission: model.ScopeUsersDelete, status: http.StatusForbidden},
+		{name: "self", principal: &model.Principal{Subject: "42", Method: model.AuthMethodJWT, Scopes: allScopes}, permission: model.ScopeUsersWrite, status: http.StatusOK},
+		{name: "api key named like the user", principal: &model.Principal{Subject: "42", Method: model.AuthMethodAPIKey, Scopes: allScopes}, permission: model.ScopeUsersWrite, status: http.StatusForbidden},
+		{name: "certificate named like the user", principal: &model.Principal{Subject: "42", Method: model.AuthMethodClientCert, Scopes: allScopes}, permission: model.ScopeUsersWrite, status: http.StatusForbidden},
+		{name: "signing key named like the user", principal: &model.Principal{Subject: "42", Method: model.AuthMethodSignature, Scopes: allScopes}, permission: model.ScopeUsersWrite, status: http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		serveAs(tc.principal, Authorize(testAuthorizer, tc.permission)).ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/users/42", nil))
+		if w.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, w.Code)
+		}
+		if w.Code == http.StatusForbidden && w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: expected a problem body, got %q", tc.name, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestAuthorizeFields(t *testing.T) {
+	editor := &model.Principal{Subject: "bob", Method: model.AuthMethodJWT, Roles: []string{"editor"}}
+	admin := &model.Principal{Subject: "root", Method: model.AuthMethodJWT, Roles: []string{"admin"}}
+
+	cases := []struct {
+		name      string
+		principal *model.Principal
+		body      string
+		status    int
+	}{
+		{name: "unrestricted field", principal: editor, body: `{"email":"bob@example.com"}`, status: http.StatusOK},
+		{name: "restricted field", principal: editor, body: `{"username":"root"}`, status: http.StatusForbidden},
+		{name: "upper case key", principal: editor, body: `{"USERNAME":"root"}`, status: http.StatusForbidden},
+		{name: "mixed case key", principal: editor, body: `{"UserName":"root"}`, status: http.StatusForbidden},
+		{name: "long s key", principal: editor, body: `{"uſername":"root"}`, status: http.StatusForbidden},
+		{name: "case variant duplicate", principal: editor, body: `{"username":"","USERNAME":"root"}`, status: http.StatusBadRequest},
+		{name: "empty value", principal: editor, body: `{"username":""}`, status: http.StatusOK},
+		{name: "admin", principal: admin, body: `{"USERNAME":"root"}`, status: http.StatusOK},
+		{name: "malformed body", principal: editor, body: `{"username":`, status: http.StatusOK},
+	}
+	for _, tc := range cases {
+		var bound model.UpdateUserRequest
+		router := serveAs(tc.principal, AuthorizeFields(testAuthorizer, "users"), func(c *gin.Context) {
+			c.ShouldBindJSON(&bound)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/users/42", strings.NewReader(tc.body)))
+		if w.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, w.Code)
+		}
+		if w.Code == http.StatusOK && tc.principal == editor && bound.Username != "" {
+			t.Errorf("%s: the handler bound username %q for a caller not allowed to change it", tc.name, bound.Username)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DatabaseAvailabilityMiddleware(available func() bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if available != nil && !available() {
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
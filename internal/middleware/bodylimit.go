@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BodyLimitOptions struct {
+	// MaxBytes caps every request body; zero or less leaves bodies
+	// unlimited.
+	MaxBytes int64
+	// Routes overrides MaxBytes for single routes, keyed by "METHOD /route".
+	Routes map[string]int64
+}
+
+// BodyLimitMiddleware rejects bodies over the route's limit with 413. A
+// declared Content-Length is checked up front; otherwise the body is capped
+// and reading past the limit fails with *http.MaxBytesError, which handlers
+// report as 413 too.
+func BodyLimitMiddleware(opts BodyLimitOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := opts.MaxBytes
+		if routeLimit, ok := opts.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+			limit = routeLimit
+		}
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			abortWithProblem(c, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
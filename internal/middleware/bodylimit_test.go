@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimitMiddleware(BodyLimitOptions{
+		MaxBytes: 16,
+		Routes:   map[string]int64{"POST /large": 64},
+	}))
+	read := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	router.POST("/small", read)
+	router.POST("/large", read)
+
+	cases := []struct {
+		path    string
+		size    int
+		chunked bool
+		status  int
+	}{
+		{path: "/small", size: 16, status: http.StatusOK},
+		{path: "/small", size: 17, status: http.StatusRequestEntityTooLarge},
+		{path: "/small", size: 17, chunked: true, status: http.StatusRequestEntityTooLarge},
+		{path: "/large", size: 64, status: http.StatusOK},
+		{path: "/large", size: 65, status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(strings.Repeat("a", tc.size)))
+		if tc.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s with %d bytes (chunked %v): expected %d, got %d", tc.path, tc.size, tc.chunked, tc.status, w.Code)
+		}
+	}
+}
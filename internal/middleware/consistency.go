@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"cruder/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReadConsistencyMiddleware(sessionHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sessionID string
+		if sessionHeader != "" {
+			sessionID = c.GetHeader(sessionHeader)
+		}
+
+		ctx := repository.WithConsistency(c.Request.Context(), sessionID)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
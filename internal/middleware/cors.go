@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CORSOptions struct {
+	// AllowedOrigins lists exact origins, "*" for any, or wildcard
+	// subdomains such as "https://*.example.com".
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge lets browsers cache preflight results.
+	MaxAge time.Duration
+}
+
+// CORSMiddleware answers preflight requests and adds the CORS headers to
+// requests from allowed origins. It has to run before authentication, since
+// browsers send preflights without credentials. Requests from other origins
+// are served without CORS headers, leaving the browser to block them.
+func CORSMiddleware(opts CORSOptions) gin.HandlerFunc {
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+	anyOrigin := slices.Contains(opts.AllowedOrigins, "*")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		if !anyOrigin && !originAllowed(opts.AllowedOrigins, origin) {
+			c.Next()
+			return
+		}
+
+		// Credentials cannot be combined with a literal "*".
+		if anyOrigin && !opts.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+			c.Header("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				c.Header("Access-Control-Allow-Headers", headers)
+			}
+			if opts.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposed != "" {
+			c.Header("Access-Control-Expose-Headers", exposed)
+		}
+		c.Next()
+	}
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if strings.EqualFold(pattern, origin) {
+			return true
+		}
+		scheme, host, ok := strings.Cut(pattern, "://*.")
+		if !ok {
+			continue
+		}
+		suffix := "." + strings.ToLower(host)
+		rest, ok := strings.CutPrefix(strings.ToLower(origin), strings.ToLower(scheme)+"://")
+		if ok && strings.HasSuffix(rest, suffix) && len(rest) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(CORSOptions{
+		AllowedOrigins:   []string{"https://admin.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "PATCH"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+	router.PATCH("/users/:uuid", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		name    string
+		method  string
+		origin  string
+		status  int
+		allowed bool
+	}{
+		{name: "preflight", method: http.MethodOptions, origin: "https://admin.example.com", status: http.StatusNoContent, allowed: true},
+		{name: "wildcard subdomain", method: http.MethodPatch, origin: "https://app.example.org", status: http.StatusOK, allowed: true},
+		{name: "bare wildcard domain", method: http.MethodPatch, origin: "https://example.org", status: http.StatusOK},
+		{name: "other origin", method: http.MethodPatch, origin: "https://evil.example.net", status: http.StatusOK},
+		{name: "other origin preflight", method: http.MethodOptions, origin: "https://evil.example.net", status: http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/users/1", nil)
+		req.Header.Set("Origin", tc.origin)
+		if tc.method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, w.Code)
+		}
+		origin := w.Header().Get("Access-Control-Allow-Origin")
+		if tc.allowed != (origin == tc.origin) {
+			t.Errorf("%s: unexpected Access-Control-Allow-Origin %q", tc.name, origin)
+		}
+		if tc.allowed && w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("%s: expected credentials to be allowed", tc.name)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, PATCH" {
+		t.Errorf("expected allowed methods, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("expected a 600s max age, got %q", got)
+	}
+}
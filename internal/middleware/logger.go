@@ -1,97 +1,152 @@
 package middleware
 
 import (
-	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"time"
 
+	"cruder/internal/logging"
+	"cruder/internal/tenancy"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LogEntry struct {
-	Timestamp            string        `json:"timestamp"`
-	RequestMethod        string        `json:"http.request.method"`
-	RequestPath          string        `json:"http.route"`
-	RequestHost          string        `json:"http.request.host"`
-	RequestRemoteAddr    string        `json:"http.request.remote_addr"`
-	ResponseStatus       int           `json:"http.response.status_code"`
-	ResponseDuration     int64         `json:"http.server.request.duration"`
-	LogLevel             string        `json:"http.log.level"`
-	Message              string        `json:"http.request.message"`
-	UserAgent            string        `json:"http.user_agent,omitempty"`
-	RequestID            string        `json:"request_id,omitempty"`
-	Error                string        `json:"error,omitempty"`
+	RequestMethod     string `json:"http.request.method"`
+	RequestRoute      string `json:"http.route"`
+	RequestPath       string `json:"url.path"`
+	RequestQuery      string `json:"url.query,omitempty"`
+	RequestHost       string `json:"http.request.host"`
+	RequestRemoteAddr string `json:"http.request.remote_addr"`
+	RequestProtocol   string `json:"network.protocol.version"`
+	RequestBodySize   int64  `json:"http.request.body.size"`
+	ResponseStatus    int    `json:"http.response.status_code"`
+	ResponseBodySize  int64  `json:"http.response.body.size"`
+	ResponseDuration  int64  `json:"http.server.request.duration"`
+	UserAgent         string `json:"http.user_agent,omitempty"`
+	Caller            string `json:"enduser.id,omitempty"`
+	Tenant            string `json:"tenant,omitempty"`
+	RequestID         string `json:"request_id,omitempty"`
+	TraceID           string `json:"trace_id,omitempty"`
+	SpanID            string `json:"span_id,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+func (e LogEntry) attrs() []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("http.request.method", e.RequestMethod),
+		slog.String("http.route", e.RequestRoute),
+		slog.String("url.path", e.RequestPath),
+		slog.String("http.request.host", e.RequestHost),
+		slog.String("http.request.remote_addr", e.RequestRemoteAddr),
+		slog.String("network.protocol.version", e.RequestProtocol),
+		slog.Int64("http.request.body.size", e.RequestBodySize),
+		slog.Int("http.response.status_code", e.ResponseStatus),
+		slog.Int64("http.response.body.size", e.ResponseBodySize),
+		slog.Int64("http.server.request.duration", e.ResponseDuration),
+	}
+	optional := [][2]string{
+		{"url.query", e.RequestQuery},
+		{"http.user_agent", e.UserAgent},
+		{"enduser.id", e.Caller},
+		{"tenant", e.Tenant},
+		{"request_id", e.RequestID},
+		{"trace_id", e.TraceID},
+		{"span_id", e.SpanID},
+		{"error", e.Error},
+	}
+	for _, field := range optional {
+		if field[1] != "" {
+			attrs = append(attrs, slog.String(field[0], field[1]))
+		}
+	}
+	return attrs
+}
+
+type AccessLogOptions struct {
+	Redactor         *Redactor
+	SampleRate       float64
+	RouteSampleRates map[string]float64
+	SlowThreshold    time.Duration
 }
 
-func JSONLoggerMiddleware() gin.HandlerFunc {
+// sampled reports whether a successful, fast request should be logged.
+// Errors and slow requests are always logged.
+func (o AccessLogOptions) sampled(route string) bool {
+	rate, ok := o.RouteSampleRates[route]
+	if !ok {
+		rate = o.SampleRate
+	}
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+func JSONLoggerMiddleware(logger *slog.Logger, opts AccessLogOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
-		requestID := c.GetString("request_id")
-		if requestID == "" {
-			requestID = c.GetHeader("X-Request-ID")
-		}
-
 		c.Next()
 
-		duration := time.Since(startTime).Milliseconds()
+		duration := time.Since(startTime)
+		status := c.Writer.Status()
+		route := c.FullPath()
+		slow := opts.SlowThreshold > 0 && duration >= opts.SlowThreshold
 
-		logLevel := "info"
-		if c.Writer.Status() >= 400 {
-			logLevel = "error"
-		} else if c.Writer.Status() >= 300 {
-			logLevel = "warning"
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400 || slow:
+			level = slog.LevelWarn
+		case !opts.sampled(route):
+			return
+		}
+
+		ctx := c.Request.Context()
+		if !logger.Enabled(ctx, level) {
+			return
+		}
+
+		path, query := c.Request.URL.Path, c.Request.URL.RawQuery
+		if opts.Redactor != nil {
+			path = opts.Redactor.Path(c)
+			query = opts.Redactor.Query(c.Request.URL.Query())
 		}
 
 		logEntry := LogEntry{
-			Timestamp:         time.Now().Format(time.RFC3339Nano),
 			RequestMethod:     c.Request.Method,
-			RequestPath:       c.Request.URL.Path,
+			RequestRoute:      route,
+			RequestPath:       path,
+			RequestQuery:      query,
 			RequestHost:       c.Request.Host,
 			RequestRemoteAddr: c.ClientIP(),
-			ResponseStatus:    c.Writer.Status(),
-			ResponseDuration:  duration,
-			LogLevel:          logLevel,
-			Message:           "Incoming request:",
+			RequestProtocol:   fmt.Sprintf("%d.%d", c.Request.ProtoMajor, c.Request.ProtoMinor),
+			RequestBodySize:   max(c.Request.ContentLength, 0),
+			ResponseStatus:    status,
+			ResponseBodySize:  int64(max(c.Writer.Size(), 0)),
+			ResponseDuration:  duration.Milliseconds(),
 			UserAgent:         c.Request.UserAgent(),
-			RequestID:         requestID,
+			Caller:            c.GetString(CallerKey),
+			RequestID:         logging.RequestID(ctx),
 		}
 
-		if len(c.Errors) > 0 {
-			logEntry.Error = c.Errors.String()
+		if tenant, ok := tenancy.FromContext(c.Request.Context()); ok {
+			logEntry.Tenant = tenant.Slug
 		}
-
-		logBytes, err := json.Marshal(logEntry)
-		if err != nil {
-			log.Printf("failed to marshal log entry: %v", err)
-			return
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			logEntry.TraceID = spanContext.TraceID().String()
+			logEntry.SpanID = spanContext.SpanID().String()
 		}
 
-		log.Printf("%s", string(logBytes))
-	}
-}
+		if len(c.Errors) > 0 {
+			logEntry.Error = c.Errors.String()
+		}
 
-func RequestIDMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			requestID = generateRequestID()
+		message := "request completed"
+		if slow {
+			message = "slow request"
 		}
-		c.Set("request_id", requestID)
-		c.Header("X-Request-ID", requestID)
-		c.Next()
+		logger.LogAttrs(ctx, level, message, logEntry.attrs()...)
 	}
 }
-
-func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(8)
-}
-
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
-}
\ No newline at end of file
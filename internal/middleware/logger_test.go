@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAccessLog_LogsRouteTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+	router := newTestRouter(logger, redactor, func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/username/jdoe?page=2", nil))
+
+	for _, want := range []string{
+		`"http.route":"/api/v1/users/username/:username"`,
+		`"url.path":"/api/v1/users/username/[REDACTED]"`,
+		`"url.query":"page=2"`,
+		`"http.response.body.size":2`,
+		`"network.protocol.version":"1.1"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %s in log, got %s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"cruder/internal/metrics"
+	"cruder/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RateLimitOptions struct {
+	Store ratelimit.Store
+	// PerIP applies to every request by client address.
+	PerIP ratelimit.Limit
+	// PerClient applies to authenticated requests by principal, so all of
+	// an API key's or token subject's requests share one bucket.
+	PerClient ratelimit.Limit
+	// Routes adds limits for single routes, keyed by "METHOD /route", and
+	// counted per principal or, without one, per address.
+	Routes map[string]ratelimit.Limit
+}
+
+// Rate limit scopes, selecting which of the configured buckets a
+// RateLimitMiddleware checks.
+const (
+	RateLimitIP     = "ip"
+	RateLimitClient = "client"
+	RateLimitRoute  = "route"
+)
+
+// rateLimitResultKey holds the tightest bucket seen so far, so a later
+// RateLimitMiddleware only replaces the headers with a tighter one.
+const rateLimitResultKey = "ratelimit.result"
+
+// RateLimitMiddleware enforces the configured token buckets of the given
+// scopes. The per-IP limit belongs before authentication, so floods of bad
+// credentials are limited before they cost a lookup; the client and route
+// limits need the principal and go after it. The headers describe the
+// bucket closest to running out. A failing store lets requests through,
+// since an outage of the limiter should not take the API down.
+func RateLimitMiddleware(opts RateLimitOptions, scopes ...string) gin.HandlerFunc {
+	type check struct {
+		scope string
+		key   string
+		limit ratelimit.Limit
+	}
+
+	return func(c *gin.Context) {
+		if opts.Store == nil {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		var checks []check
+		if slices.Contains(scopes, RateLimitIP) {
+			checks = append(checks, check{RateLimitIP, client, opts.PerIP})
+		}
+		if principal, ok := currentPrincipal(c); ok {
+			client = "client:" + principal.Method + ":" + principal.Subject
+			if slices.Contains(scopes, RateLimitClient) {
+				checks = append(checks, check{RateLimitClient, client, opts.PerClient})
+			}
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		if limit, ok := opts.Routes[route]; ok && slices.Contains(scopes, RateLimitRoute) {
+			checks = append(checks, check{RateLimitRoute, "route:" + route + ":" + client, limit})
+		}
+
+		var tightest *ratelimit.Result
+		if previous, ok := c.Get(rateLimitResultKey); ok {
+			tightest = previous.(*ratelimit.Result)
+		}
+		for _, check := range checks {
+			if !check.limit.Enabled() {
+				continue
+			}
+			result, err := opts.Store.Allow(c.Request.Context(), check.key, check.limit)
+			if err != nil {
+				c.Error(err)
+				continue
+			}
+			if tightest == nil || !result.Allowed || result.Remaining < tightest.Remaining {
+				tightest = &result
+			}
+			if !result.Allowed {
+				metrics.RateLimited(check.scope)
+				break
+			}
+		}
+		if tightest == nil {
+			c.Next()
+			return
+		}
+		c.Set(rateLimitResultKey, tightest)
+
+		c.Header("RateLimit-Policy", tightest.Limit.Policy())
+		c.Header("RateLimit-Limit", strconv.Itoa(tightest.Limit.Requests))
+		c.Header("RateLimit-Remaining", strconv.Itoa(max(tightest.Remaining, 0)))
+		c.Header("RateLimit-Reset", ceilSeconds(tightest.Reset))
+		if !tightest.Allowed {
+			c.Header("Retry-After", ceilSeconds(tightest.RetryAfter))
+			abortWithProblem(c, http.StatusTooManyRequests, "rate limit exceeded, retry after "+ceilSeconds(tightest.RetryAfter)+"s")
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
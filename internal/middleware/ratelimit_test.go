@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+	"cruder/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitMiddleware_IgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		trusted []string
+		limited bool
+	}{
+		{name: "no trusted proxies", limited: true},
+		{name: "peer is a trusted proxy", trusted: []string{"10.0.0.0/8"}, limited: false},
+	}
+	for _, tc := range cases {
+		router := gin.New()
+		if err := router.SetTrustedProxies(tc.trusted); err != nil {
+			t.Fatal(err)
+		}
+		router.Use(RateLimitMiddleware(RateLimitOptions{
+			Store: ratelimit.NewMemoryStore(),
+			PerIP: ratelimit.Limit{Requests: 1, Period: time.Hour},
+		}, RateLimitIP))
+		router.GET("/users/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		var last int
+		for _, forwarded := range []string{"203.0.113.1", "203.0.113.2"} {
+			req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+			req.RemoteAddr = "10.0.0.5:40000"
+			req.Header.Set("X-Forwarded-For", forwarded)
+			req.Header.Set("X-Real-IP", forwarded)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			last = w.Code
+		}
+		if limited := last == http.StatusTooManyRequests; limited != tc.limited {
+			t.Errorf("%s: expected the second address to be limited=%v, got status %d", tc.name, tc.limited, last)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func TestRateLimitMiddleware_HeadersAndDenial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	opts := RateLimitOptions{
+		Store:     ratelimit.NewMemoryStore(),
+		PerIP:     ratelimit.Limit{Requests: 100, Period: time.Minute},
+		PerClient: ratelimit.Limit{Requests: 2, Period: time.Minute},
+	}
+	router := gin.New()
+	router.Use(RateLimitMiddleware(opts, RateLimitIP))
+	router.Use(func(c *gin.Context) {
+		c.Set(PrincipalKey, &model.Principal{Subject: "billing", Method: model.AuthMethodAPIKey})
+	})
+	router.Use(RateLimitMiddleware(opts, RateLimitClient, RateLimitRoute))
+	router.GET("/users/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, remaining := range []string{"1", "0"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+		// The client bucket is tighter than the address bucket, so its
+		// numbers replace those set before authentication.
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: expected RateLimit-Limit 2, got %q", i, got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != remaining {
+			t.Errorf("request %d: expected RateLimit-Remaining %s, got %q", i, remaining, got)
+		}
+		if w.Header().Get("RateLimit-Policy") == "" || w.Header().Get("RateLimit-Reset") == "" {
+			t.Errorf("request %d: expected policy and reset headers, got %v", i, w.Header())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("expected Retry-After 30, got %q", got)
+	}
+	var body struct {
+		Status int    `json:"status"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected a problem body, got %q (%v)", w.Body.String(), err)
+	}
+	if body.Status != http.StatusTooManyRequests || body.Title != "Too Many Requests" || body.Detail == "" {
+		t.Errorf("unexpected problem %+v", body)
+	}
+}
+
+func TestRateLimitMiddleware_IPLimitRunsBeforeAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticated := 0
+	router := gin.New()
+	router.Use(RateLimitMiddleware(RateLimitOptions{
+		Store: ratelimit.NewMemoryStore(),
+		PerIP: ratelimit.Limit{Requests: 1, Period: time.Hour},
+	}, RateLimitIP))
+	router.Use(func(c *gin.Context) {
+		authenticated++
+		c.AbortWithStatus(http.StatusForbidden)
+	})
+	router.GET("/users/", func(c *gin.Context) {})
+
+	statuses := make([]int, 3)
+	for i := range statuses {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/", nil))
+		statuses[i] = w.Code
+	}
+	if statuses[0] != http.StatusForbidden || statuses[1] != http.StatusTooManyRequests || authenticated != 1 {
+		t.Errorf("expected failed credentials to be limited before authentication, got %v after %d lookups", statuses, authenticated)
+	}
+}
+
+func TestRateLimitMiddleware_FailsOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var errs []string
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		errs = append(errs, c.Errors.Errors()...)
+	})
+	router.Use(RateLimitMiddleware(RateLimitOptions{
+		Store: failingStore{},
+		PerIP: ratelimit.Limit{Requests: 1, Period: time.Hour},
+	}, RateLimitIP))
+	router.GET("/users/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/", nil))
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("request %d: expected the request through without headers, got %d %v", i, w.Code, w.Header())
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected the store errors to be recorded, got %v", errs)
+	}
+}
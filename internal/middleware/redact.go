@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const redacted = "[REDACTED]"
+
+var builtinPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
+	regexp.MustCompile(`\+[1-9]\d{7,14}\b`),
+	regexp.MustCompile(`(?i)bearer\s+[a-z0-9._~+/=-]+`),
+}
+
+type RedactionOptions struct {
+	PathParams  []string
+	QueryParams []string
+	Headers     []string
+	Patterns    []string
+}
+
+// Redactor masks personal data and credentials before they are written to
+// logs or returned in error messages.
+type Redactor struct {
+	pathParams  map[string]bool
+	queryParams map[string]bool
+	headers     map[string]bool
+	patterns    []*regexp.Regexp
+}
+
+func NewRedactor(opts RedactionOptions) (*Redactor, error) {
+	r := &Redactor{
+		pathParams:  toSet(opts.PathParams, false),
+		queryParams: toSet(opts.QueryParams, true),
+		headers:     toSet(opts.Headers, true),
+		patterns:    append([]*regexp.Regexp{}, builtinPatterns...),
+	}
+	for _, pattern := range opts.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func toSet(values []string, fold bool) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		if fold {
+			value = strings.ToLower(value)
+		}
+		set[value] = true
+	}
+	return set
+}
+
+// String masks every known PII pattern in s.
+func (r *Redactor) String(s string) string {
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+	return s
+}
+
+// Path replaces the values of configured route parameters in the request
+// path, then masks anything else that looks like PII.
+func (r *Redactor) Path(c *gin.Context) string {
+	path := c.Request.URL.Path
+	for _, param := range c.Params {
+		if r.pathParams[param.Key] && param.Value != "" {
+			path = strings.ReplaceAll(path, "/"+param.Value, "/"+redacted)
+		}
+	}
+	return r.String(path)
+}
+
+func (r *Redactor) Query(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+	masked := make(url.Values, len(values))
+	for key, vals := range values {
+		for _, value := range vals {
+			if r.queryParams[strings.ToLower(key)] {
+				value = redacted
+			} else {
+				value = r.String(value)
+			}
+			masked.Add(r.String(key), value)
+		}
+	}
+	encoded, _ := url.QueryUnescape(masked.Encode())
+	return encoded
+}
+
+func (r *Redactor) Header(name, value string) string {
+	if r.headers[strings.ToLower(name)] {
+		return redacted
+	}
+	return r.String(value)
+}
+
+// ReplaceAttr is a slog.HandlerOptions.ReplaceAttr hook that masks attributes
+// named after sensitive headers and scrubs PII from string and error values.
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if r.headers[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.String(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			return slog.String(a.Key, r.String(v.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, r.String(v.String()))
+		case []byte:
+			return slog.String(a.Key, r.String(string(v)))
+		default:
+			return slog.String(a.Key, r.String(fmt.Sprint(v)))
+		}
+	}
+	return a
+}
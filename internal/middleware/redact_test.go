@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cruder/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testEmail = "jdoe@example.com"
+
+func newTestRedactor(t *testing.T) *Redactor {
+	t.Helper()
+	redactor, err := NewRedactor(RedactionOptions{
+		PathParams:  []string{"username"},
+		QueryParams: []string{"email"},
+		Headers:     []string{"X-API-Key", "Authorization"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return redactor
+}
+
+func newTestLogger(t *testing.T, redactor *Redactor, buf *bytes.Buffer) *slog.Logger {
+	t.Helper()
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactor.ReplaceAttr,
+	}))
+}
+
+func newTestRouter(logger *slog.Logger, redactor *Redactor, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RecoveryMiddleware(logger))
+	router.Use(RequestIDMiddleware(RequestIDOptions{}))
+	router.Use(JSONLoggerMiddleware(logger, AccessLogOptions{Redactor: redactor, SampleRate: 1}))
+	router.GET("/api/v1/users/username/:username", handler)
+	return router
+}
+
+func assertNoEmail(t *testing.T, buf *bytes.Buffer) {
+	t.Helper()
+	if buf.Len() == 0 {
+		t.Fatal("expected log output, got none")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if strings.Contains(line, "@example.com") {
+			t.Errorf("expected no email in log line, got %s", line)
+		}
+	}
+}
+
+func TestAccessLog_EmailInPathIsRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+	router := newTestRouter(logger, redactor, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/username/"+url.PathEscape(testEmail)+"?email="+url.QueryEscape(testEmail), nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assertNoEmail(t, &buf)
+	if !strings.Contains(buf.String(), "/api/v1/users/username/[REDACTED]") {
+		t.Errorf("expected redacted path in log, got %s", buf.String())
+	}
+}
+
+func TestAccessLog_ConfiguredPathParamIsRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+	router := newTestRouter(logger, redactor, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/username/jdoe", nil))
+
+	if strings.Contains(buf.String(), "jdoe") {
+		t.Errorf("expected username to be redacted, got %s", buf.String())
+	}
+}
+
+func TestAccessLog_ErrorsAreRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+	router := newTestRouter(logger, redactor, func(c *gin.Context) {
+		c.Error(errors.New(`pq: duplicate key value violates unique constraint "users_email_key": Key (email)=(` + testEmail + `) already exists`))
+		c.Status(http.StatusInternalServerError)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/username/jdoe", nil))
+
+	assertNoEmail(t, &buf)
+}
+
+func TestAccessLog_PanicIsRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+	router := newTestRouter(logger, redactor, func(c *gin.Context) {
+		panic("unexpected user " + testEmail)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/username/jdoe", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	assertNoEmail(t, &buf)
+}
+
+func TestApplicationLog_EmailIsRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+
+	type user struct {
+		Email string
+	}
+
+	logger.Info("created user "+testEmail,
+		"email", testEmail,
+		"error", errors.New("lookup failed for "+testEmail),
+		"user", user{Email: testEmail},
+		"raw", []byte(testEmail),
+		slog.Group("request", slog.String("body", `{"email":"`+testEmail+`"}`)),
+	)
+	logger.With("email", testEmail).Warn("with attrs")
+	logger.WithGroup("audit").Error("grouped", "email", testEmail)
+
+	assertNoEmail(t, &buf)
+}
+
+func TestApplicationLog_ContextHandlerIsRedacted(t *testing.T) {
+	redactor := newTestRedactor(t)
+	output := filepath.Join(t.TempDir(), "app.log")
+
+	logger, closer, err := logging.New(logging.Options{Output: output, ReplaceAttr: redactor.ReplaceAttr})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	ctx := logging.WithRequestID(context.Background(), testEmail)
+	logger.InfoContext(ctx, "lookup "+testEmail, "email", testEmail)
+	closer.Close()
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	assertNoEmail(t, bytes.NewBuffer(data))
+}
+
+func TestRedactor_SensitiveHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	redactor := newTestRedactor(t)
+	logger := newTestLogger(t, redactor, &buf)
+
+	logger.Info("auth", "X-API-Key", "secret-key", "authorization", "Bearer abc.def.ghi")
+
+	if strings.Contains(buf.String(), "secret-key") || strings.Contains(buf.String(), "abc.def.ghi") {
+		t.Errorf("expected credentials to be redacted, got %s", buf.String())
+	}
+	if got := redactor.Header("X-Api-Key", "secret-key"); got != redacted {
+		t.Errorf("expected header to be redacted, got %s", got)
+	}
+	if got := redactor.Header("Accept", "application/json"); got != "application/json" {
+		t.Errorf("expected header to be kept, got %s", got)
+	}
+}
+
+func TestRedactor_Query(t *testing.T) {
+	redactor := newTestRedactor(t)
+
+	got := redactor.Query(url.Values{
+		"email": {"someone"},
+		"q":     {testEmail},
+		"page":  {"2"},
+	})
+
+	if strings.Contains(got, "someone") || strings.Contains(got, "@example.com") {
+		t.Errorf("expected query to be redacted, got %s", got)
+	}
+	if !strings.Contains(got, "page=2") {
+		t.Errorf("expected unrelated parameters to be kept, got %s", got)
+	}
+}
+
+func TestNewRedactor_InvalidPattern(t *testing.T) {
+	_, err := NewRedactor(RedactionOptions{Patterns: []string{"("}})
+
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"cruder/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	RequestIDFormatUUIDv4 = "uuidv4"
+	RequestIDFormatUUIDv7 = "uuidv7"
+	RequestIDFormatULID   = "ulid"
+
+	defaultRequestIDMaxLength = 64
+)
+
+type RequestIDOptions struct {
+	Generator       RequestIDGenerator
+	MaxLength       int
+	TrustIncoming   bool
+	FromTraceparent bool
+}
+
+type RequestIDGenerator func() string
+
+// NewRequestIDGenerator returns a generator for the given format. All formats
+// draw their randomness from crypto/rand.
+func NewRequestIDGenerator(format string) (RequestIDGenerator, error) {
+	switch format {
+	case RequestIDFormatUUIDv4:
+		return newUUIDv4, nil
+	case RequestIDFormatUUIDv7, "":
+		return newUUIDv7, nil
+	case RequestIDFormatULID:
+		return newULID, nil
+	default:
+		return nil, fmt.Errorf("unknown request id format %q", format)
+	}
+}
+
+func newUUIDv4() string {
+	return uuid.Must(uuid.NewRandom()).String()
+}
+
+func newUUIDv7() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID encodes a 48-bit millisecond timestamp followed by 80 random bits
+// as 26 Crockford base32 characters.
+func newULID() string {
+	var id [16]byte
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], ts[2:])
+	if _, err := rand.Read(id[6:]); err != nil {
+		panic(err)
+	}
+
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// validRequestID accepts IDs made of characters that are safe to echo in
+// headers, write to logs and embed in SQL comments.
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// traceIDFromTraceparent extracts the trace id from a W3C traceparent header.
+func traceIDFromTraceparent(header string) string {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || parts[0] == "ff" {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if _, err := hex.DecodeString(traceID); err != nil || traceID == strings.Repeat("0", 32) {
+		return ""
+	}
+	return traceID
+}
+
+func RequestIDMiddleware(opts RequestIDOptions) gin.HandlerFunc {
+	generate := opts.Generator
+	if generate == nil {
+		generate = newUUIDv7
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = defaultRequestIDMaxLength
+	}
+
+	return func(c *gin.Context) {
+		requestID := ""
+		if opts.TrustIncoming {
+			if incoming := c.GetHeader(RequestIDHeader); validRequestID(incoming, opts.MaxLength) {
+				requestID = incoming
+			}
+		}
+		if requestID == "" && opts.FromTraceparent {
+			requestID = traceIDFromTraceparent(c.GetHeader("traceparent"))
+		}
+		if requestID == "" {
+			requestID = generate()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
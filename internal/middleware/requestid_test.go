@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewRequestIDGenerator_Formats(t *testing.T) {
+	formats := map[string]*regexp.Regexp{
+		RequestIDFormatUUIDv4: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		RequestIDFormatUUIDv7: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`),
+		RequestIDFormatULID:   regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`),
+	}
+
+	for format, pattern := range formats {
+		generate, err := NewRequestIDGenerator(format)
+		if err != nil {
+			t.Fatalf("expected no error for %s, got %v", format, err)
+		}
+
+		seen := make(map[string]bool)
+		for i := 0; i < 10000; i++ {
+			id := generate()
+			if !pattern.MatchString(id) {
+				t.Fatalf("expected %s id, got %s", format, id)
+			}
+			if seen[id] {
+				t.Fatalf("expected unique %s ids, got duplicate %s", format, id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestNewRequestIDGenerator_UnknownFormat(t *testing.T) {
+	_, err := NewRequestIDGenerator("sequential")
+
+	if err == nil {
+		t.Error("expected error, got nil")
+	}
+}
+
+func serveRequestID(opts RequestIDOptions, headers map[string]string) string {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware(opts))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Header().Get(RequestIDHeader)
+}
+
+func TestRequestIDMiddleware_Incoming(t *testing.T) {
+	opts := RequestIDOptions{TrustIncoming: true, MaxLength: 16}
+
+	if got := serveRequestID(opts, map[string]string{RequestIDHeader: "abc-123"}); got != "abc-123" {
+		t.Errorf("expected incoming id to be kept, got %s", got)
+	}
+	for _, incoming := range []string{"abc */ DROP TABLE users", strings.Repeat("a", 17), "<script>"} {
+		if got := serveRequestID(opts, map[string]string{RequestIDHeader: incoming}); got == incoming || got == "" {
+			t.Errorf("expected invalid id %q to be replaced, got %s", incoming, got)
+		}
+	}
+
+	opts.TrustIncoming = false
+	if got := serveRequestID(opts, map[string]string{RequestIDHeader: "abc-123"}); got == "abc-123" {
+		t.Error("expected incoming id to be ignored")
+	}
+}
+
+func TestRequestIDMiddleware_Traceparent(t *testing.T) {
+	opts := RequestIDOptions{FromTraceparent: true}
+
+	got := serveRequestID(opts, map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	if got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected trace id, got %s", got)
+	}
+
+	got = serveRequestID(opts, map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"})
+	if got == "" || strings.HasPrefix(got, "0000") {
+		t.Errorf("expected generated id for invalid traceparent, got %s", got)
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SecurityHeadersOptions
+		want map[string]string
+	}{
+		{
+			name: "all headers",
+			opts: SecurityHeadersOptions{
+				HSTSMaxAge:            365 * 24 * time.Hour,
+				HSTSIncludeSubdomains: true,
+				ContentSecurityPolicy: "default-src 'none'",
+				FrameOptions:          "DENY",
+				ReferrerPolicy:        "no-referrer",
+			},
+			want: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+				"Content-Security-Policy":   "default-src 'none'",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "no-referrer",
+			},
+		},
+		{
+			name: "hsts without subdomains",
+			opts: SecurityHeadersOptions{HSTSMaxAge: time.Hour},
+			want: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"Strict-Transport-Security": "max-age=3600",
+			},
+		},
+		{
+			name: "empty options",
+			want: map[string]string{"X-Content-Type-Options": "nosniff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(SecurityHeadersMiddleware(tt.opts))
+			router.GET("/users/", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/", nil))
+
+			for _, name := range []string{"X-Content-Type-Options", "Strict-Transport-Security", "Content-Security-Policy", "X-Frame-Options", "Referrer-Policy"} {
+				if got := w.Header().Get(name); got != tt.want[name] {
+					t.Errorf("expected %s %q, got %q", name, tt.want[name], got)
+				}
+			}
+		})
+	}
+}
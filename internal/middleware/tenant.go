@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"cruder/internal/model"
+	"cruder/internal/tenancy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantKey holds the *model.Tenant the request is scoped to.
+const TenantKey = "tenant"
+
+type TenantResolver interface {
+	Resolve(ctx context.Context, slug string) (*model.Tenant, error)
+}
+
+type TenantOptions struct {
+	// Header names the request header carrying a tenant slug.
+	Header string
+	// DefaultTenant is used when neither the credentials nor the header name
+	// a tenant. Empty makes a tenant mandatory.
+	DefaultTenant string
+}
+
+// TenantMiddleware scopes the request to a tenant, taken from the
+// credentials when they are bound to one. Only API keys an operator left
+// unbound, the static key among them, may pick a tenant with the header;
+// tokens, certificates and signing keys without a tenant are pinned to the
+// default tenant, since their subjects are not unique across tenants. A
+// header naming another tenant than the credentials is rejected.
+func TenantMiddleware(resolver TenantResolver, opts TenantOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var slug string
+		principal, authenticated := currentPrincipal(c)
+		if authenticated {
+			slug = principal.Tenant
+			if slug == "" && principal.Method != model.AuthMethodAPIKey {
+				if opts.DefaultTenant == "" {
+					abortWithProblem(c, http.StatusForbidden, "credentials are not bound to a tenant")
+					return
+				}
+				slug = opts.DefaultTenant
+			}
+		}
+		if header := c.GetHeader(opts.Header); header != "" {
+			if slug != "" && header != slug {
+				abortWithProblem(c, http.StatusForbidden, "credentials are bound to another tenant")
+				return
+			}
+			slug = header
+		}
+		if slug == "" {
+			slug = opts.DefaultTenant
+		}
+		if slug == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing " + opts.Header + " header"})
+			return
+		}
+
+		tenant, err := resolver.Resolve(c.Request.Context(), slug)
+		if err != nil {
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "tenant resolution unavailable"})
+			return
+		}
+		if tenant == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+			return
+		}
+
+		c.Set(TenantKey, tenant)
+		c.Request = c.Request.WithContext(tenancy.WithTenant(c.Request.Context(), *tenant))
+		c.Next()
+	}
+}
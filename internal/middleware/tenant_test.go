@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cruder/internal/model"
+	"cruder/internal/tenancy"
+
+	"github.com/gin-gonic/gin"
+)
+
+type staticTenants map[string]model.Tenant
+
+func (s staticTenants) Resolve(ctx context.Context, slug string) (*model.Tenant, error) {
+	if tenant, ok := s[slug]; ok {
+		return &tenant, nil
+	}
+	return nil, nil
+}
+
+func TestTenantMiddleware_Resolution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenants := staticTenants{
+		"default": {ID: 1, Slug: "default"},
+		"sales":   {ID: 2, Slug: "sales"},
+	}
+
+	cases := []struct {
+		name      string
+		bound     string
+		header    string
+		defaultTo string
+		status    int
+		tenantID  int
+	}{
+		{name: "default", defaultTo: "default", status: http.StatusOK, tenantID: 1},
+		{name: "header", header: "sales", defaultTo: "default", status: http.StatusOK, tenantID: 2},
+		{name: "bound credentials", bound: "sales", defaultTo: "default", status: http.StatusOK, tenantID: 2},
+		{name: "matching header", bound: "sales", header: "sales", status: http.StatusOK, tenantID: 2},
+		{name: "other tenant header", bound: "sales", header: "default", status: http.StatusForbidden},
+		{name: "unknown tenant", header: "marketing", status: http.StatusNotFound},
+		{name: "no tenant", status: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set(PrincipalKey, &model.Principal{Subject: "caller", Method: model.AuthMethodAPIKey, Tenant: tc.bound})
+		})
+		router.Use(TenantMiddleware(tenants, TenantOptions{Header: "X-Tenant-ID", DefaultTenant: tc.defaultTo}))
+
+		var tenantID int
+		router.GET("/", func(c *gin.Context) {
+			tenant, _ := tenancy.FromContext(c.Request.Context())
+			tenantID = tenant.ID
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set("X-Tenant-ID", tc.header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, w.Code)
+		}
+		if tenantID != tc.tenantID {
+			t.Errorf("%s: expected tenant %d, got %d", tc.name, tc.tenantID, tenantID)
+		}
+	}
+}
+
+func TestTenantMiddleware_PinsUnboundTokens(t *testing.T) {
+	tenants := staticTenants{
+		"default": {ID: 1, Slug: "default"},
+		"sales":   {ID: 2, Slug: "sales"},
+	}
+	jdoe := &model.Principal{Subject: "jdoe", Method: model.AuthMethodJWT, Scopes: []string{model.ScopeUsersWrite}}
+
+	cases := []struct {
+		name      string
+		header    string
+		defaultTo string
+		status    int
+	}{
+		{name: "own record in the default tenant", defaultTo: "default", status: http.StatusOK},
+		{name: "same username in another tenant", header: "sales", defaultTo: "default", status: http.StatusForbidden},
+		{name: "no default tenant", header: "sales", status: http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		router := serveAs(jdoe,
+			TenantMiddleware(tenants, TenantOptions{Header: "X-Tenant-ID", DefaultTenant: tc.defaultTo}),
+			Authorize(testAuthorizer, model.ScopeUsersWrite),
+		)
+		req := httptest.NewRequest(http.MethodPatch, "/users/jdoe", nil)
+		if tc.header != "" {
+			req.Header.Set("X-Tenant-ID", tc.header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, w.Code)
+		}
+	}
+}
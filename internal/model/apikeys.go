@@ -0,0 +1,92 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrExpiredAPIKey = errors.New("api key expired")
+	ErrRevokedAPIKey = errors.New("api key revoked")
+)
+
+const (
+	ScopeUsersRead   = "users:read"
+	ScopeUsersWrite  = "users:write"
+	ScopeUsersDelete = "users:delete"
+)
+
+var Scopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeUsersDelete}
+
+type APIKey struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Prefix  string   `json:"-"`
+	KeyHash string   `json:"-"`
+	Scopes  []string `json:"scopes"`
+	// Tenant binds the key to one tenant; keys without one may pick any
+	// tenant per request.
+	Tenant      string     `json:"tenant,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	RotatedFrom *int       `json:"rotated_from,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// MarshalJSON adds a masked form of the key so operators can tell keys apart
+// without the secret ever being returned after creation.
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	type apiKey APIKey
+	return json.Marshal(struct {
+		apiKey
+		Key string `json:"key"`
+	}{apiKey(k), k.Masked()})
+}
+
+// APIKeyPrefixLength is the length of the hex prefix of generated keys.
+const APIKeyPrefixLength = 12
+
+// APIKeyPrefix returns the public prefix of a key of the form
+// crd_<prefix>_<secret>. Keys whose prefix could not have been generated are
+// rejected, so they never reach a lookup.
+func APIKeyPrefix(rawKey string) (string, bool) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != "crd" || parts[2] == "" || !ValidAPIKeyPrefix(parts[1]) {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// ValidAPIKeyPrefix reports whether prefix has the form of a generated one.
+func ValidAPIKeyPrefix(prefix string) bool {
+	if len(prefix) != APIKeyPrefixLength {
+		return false
+	}
+	for _, c := range prefix {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (k *APIKey) Masked() string {
+	return "crd_" + k.Prefix + "_****"
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+const (
+	BanKindIP     = "ip"
+	BanKindPrefix = "prefix"
+)
+
+// Ban is a client address or API key prefix that is temporarily refused
+// authentication after repeated failures.
+type Ban struct {
+	Kind     string    `json:"kind"`
+	Value    string    `json:"value"`
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until"`
+}
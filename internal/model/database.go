@@ -0,0 +1,31 @@
+package model
+
+type MigrationStatus struct {
+	Applied  int64 `json:"applied"`
+	Expected int64 `json:"expected,omitempty"`
+}
+
+type PoolStats struct {
+	Name               string `json:"name"`
+	Healthy            bool   `json:"healthy"`
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDurationMs     int64  `json:"wait_duration_ms"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+type QueryStats struct {
+	Statement string  `json:"statement"`
+	Calls     int64   `json:"calls"`
+	Errors    int64   `json:"errors"`
+	SlowCalls int64   `json:"slow_calls"`
+	Rows      int64   `json:"rows"`
+	TotalMs   float64 `json:"total_ms"`
+	MeanMs    float64 `json:"mean_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
@@ -1,6 +1,7 @@
 package model
 
 type ValidationError struct {
+	Rule    string
 	Message string
 }
 
@@ -10,4 +11,8 @@ func (e *ValidationError) Error() string {
 
 func NewValidationError(msg string) *ValidationError {
 	return &ValidationError{Message: msg}
+}
+
+func NewRuleValidationError(rule, msg string) *ValidationError {
+	return &ValidationError{Rule: rule, Message: msg}
 }
\ No newline at end of file
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"errors"
+	"slices"
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid bearer token")
+	ErrInvalidSignature = errors.New("invalid request signature")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+const (
+	AuthMethodAPIKey = "api_key"
+	AuthMethodJWT    = "jwt"
+	// AuthMethodClientCert is a certificate verified during the mutual TLS
+	// handshake.
+	AuthMethodClientCert = "client_cert"
+	// AuthMethodSignature is a request signed with a shared HMAC secret.
+	AuthMethodSignature = "signature"
+)
+
+// Principal is the authenticated caller, whichever scheme it used.
+type Principal struct {
+	Subject string
+	Method  string
+	Scopes  []string
+	// Roles are the roles asserted by the credential itself, such as a JWT
+	// roles claim; the access policy may bind more.
+	Roles []string
+	// Tenant is the slug of the tenant the credential is bound to, if any.
+	Tenant string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
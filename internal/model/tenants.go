@@ -0,0 +1,24 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultTenant is the tenant existing users were moved to when tenants
+// were introduced.
+const DefaultTenant = "default"
+
+var ErrTenantRequired = errors.New("no tenant in context")
+
+type Tenant struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateTenantRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore keeps buckets in process. Each replica then enforces the
+// limits on its own, so the effective limit scales with the replica count.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now(), now: time.Now}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), updated: now}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = min(limit.burst(), b.tokens+now.Sub(b.updated).Seconds()*limit.perSecond())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return result(limit, allowed, b.tokens), nil
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// starts out full anyway.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.limit.perSecond() >= b.limit.burst() {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
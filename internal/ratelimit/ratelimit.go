@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket refilled with Requests tokens per Period and
+// holding at most Burst tokens. Burst defaults to Requests.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// perSecond is the refill rate in tokens per second.
+func (l Limit) perSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Policy describes the limit in the RateLimit-Policy header format.
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", l.Requests, int(math.Ceil(l.Period.Seconds())), int(l.burst()))
+}
+
+type Result struct {
+	Limit   Limit
+	Allowed bool
+	// Remaining is the number of whole tokens left after this request.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a denied request would be allowed.
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Allow takes one token from the bucket identified by key.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result derives the response fields from the tokens left in a bucket.
+func result(limit Limit, allowed bool, tokens float64) Result {
+	rate := limit.perSecond()
+	r := Result{
+		Limit:     limit,
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((limit.burst() - tokens) / rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(s, 0) * float64(time.Second))
+}
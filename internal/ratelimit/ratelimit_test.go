@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newStores(t *testing.T, c *clock) map[string]Store {
+	t.Helper()
+	memory := NewMemoryStore()
+	memory.now = c.Now
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	shared := NewRedisStore(client, "test:")
+	shared.now = c.Now
+
+	return map[string]Store{"memory": memory, "redis": shared}
+}
+
+func TestStore_TokenBucket(t *testing.T) {
+	c := &clock{now: time.Unix(1_760_000_000, 0)}
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+
+	for name, store := range newStores(t, c) {
+		for i := 0; i < 3; i++ {
+			result, err := store.Allow(context.Background(), "client:a", limit)
+			if err != nil {
+				t.Fatalf("%s: expected no error, got %v", name, err)
+			}
+			if !result.Allowed || result.Remaining != 2-i {
+				t.Errorf("%s: request %d: unexpected result %+v", name, i, result)
+			}
+		}
+
+		result, err := store.Allow(context.Background(), "client:a", limit)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if result.Allowed || result.RetryAfter != time.Second {
+			t.Errorf("%s: expected a denial with a one second retry, got %+v", name, result)
+		}
+
+		if result, _ := store.Allow(context.Background(), "client:b", limit); !result.Allowed {
+			t.Errorf("%s: expected buckets to be independent", name)
+		}
+
+		c.now = c.now.Add(time.Second)
+		if result, _ := store.Allow(context.Background(), "client:a", limit); !result.Allowed || result.Remaining != 0 {
+			t.Errorf("%s: expected one token after a second, got %+v", name, result)
+		}
+		c.now = c.now.Add(-time.Second)
+	}
+}
+
+func TestRedisStore_SharedBetweenReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	limit := Limit{Requests: 2, Period: time.Hour}
+
+	var replicas []*RedisStore
+	for i := 0; i < 2; i++ {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		replicas = append(replicas, NewRedisStore(client, "test:"))
+	}
+
+	for i, replica := range []*RedisStore{replicas[0], replicas[1], replicas[0]} {
+		result, err := replica.Allow(context.Background(), "ip:10.0.0.1", limit)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.Allowed != (i < 2) {
+			t.Errorf("request %d: expected allowed=%v, got %+v", i, i < 2, result)
+		}
+	}
+	if ttl := server.TTL("test:ip:10.0.0.1"); ttl <= 0 {
+		t.Errorf("expected the bucket to expire, got ttl %v", ttl)
+	}
+}
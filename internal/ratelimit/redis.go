@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucket refills and takes from the bucket in KEYS[1] atomically. The
+// clock comes from the caller, so replicas need reasonably synchronised
+// clocks; a clock that goes backwards never adds tokens.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+  tokens = burst
+  updated = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore shares buckets between replicas through Redis, so limits hold
+// for the deployment as a whole.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	perMilli := limit.perSecond() / 1000
+	now := s.now().UnixMilli()
+
+	values, err := tokenBucket.Run(ctx, s.client, []string{s.prefix + key},
+		strconv.FormatFloat(perMilli, 'g', -1, 64),
+		strconv.FormatFloat(limit.burst(), 'g', -1, 64),
+		now,
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensText, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, allowed == 1, tokens), nil
+}
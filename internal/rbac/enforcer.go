@@ -0,0 +1,87 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"cruder/internal/audit"
+	"cruder/internal/model"
+)
+
+// Enforcer decides requests against a Policy and audit logs every denial.
+type Enforcer struct {
+	policy *Policy
+	audit  *audit.Logger
+}
+
+func NewEnforcer(policy *Policy, logger *slog.Logger) *Enforcer {
+	return &Enforcer{policy: policy, audit: audit.New(logger)}
+}
+
+// Roles returns the roles of principal: those its credential asserts plus
+// those bound to it by the policy. Roles the policy does not define are
+// dropped so a token cannot invent one.
+func (e *Enforcer) Roles(principal *model.Principal) []string {
+	subject := principal.Method + ":" + principal.Subject
+
+	var roles []string
+	add := func(role string) {
+		if _, ok := e.policy.Roles[role]; ok && role != RoleSelf && !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	for _, role := range principal.Roles {
+		add(role)
+	}
+	for _, binding := range e.policy.Bindings {
+		if binding.Subject == subject {
+			for _, role := range binding.Roles {
+				add(role)
+			}
+		}
+	}
+	return roles
+}
+
+// Authorize checks that principal may use permission. self reports whether
+// the request targets the principal's own record, which grants the self role
+// to identity provider tokens only.
+func (e *Enforcer) Authorize(ctx context.Context, principal *model.Principal, permission string, self bool) error {
+	roles := e.effectiveRoles(principal, self)
+	for _, role := range roles {
+		permissions := e.policy.Roles[role].Permissions
+		if slices.Contains(permissions, permission) || slices.Contains(permissions, AnyPermission) {
+			return nil
+		}
+	}
+	e.audit.Record(ctx, "authorization.check", audit.OutcomeDenied,
+		"permission", permission, "roles", roles, "auth.method", principal.Method)
+	return fmt.Errorf("%w: %s", model.ErrPermissionDenied, permission)
+}
+
+// AuthorizeField checks that principal may change field of resource.
+func (e *Enforcer) AuthorizeField(ctx context.Context, principal *model.Principal, resource, field string, self bool) error {
+	allowed, ok := e.policy.Fields[resource+"."+field]
+	if !ok {
+		return nil
+	}
+	roles := e.effectiveRoles(principal, self)
+	for _, role := range roles {
+		if slices.Contains(allowed, role) {
+			return nil
+		}
+	}
+	e.audit.Record(ctx, "authorization.field", audit.OutcomeDenied,
+		"resource", resource, "field", field, "roles", roles, "auth.method", principal.Method)
+	return fmt.Errorf("%w: only %v may change %s", model.ErrPermissionDenied, allowed, field)
+}
+
+func (e *Enforcer) effectiveRoles(principal *model.Principal, self bool) []string {
+	roles := e.Roles(principal)
+	if _, ok := e.policy.Roles[RoleSelf]; ok && self && principal.Method == model.AuthMethodJWT {
+		roles = append(roles, RoleSelf)
+	}
+	return roles
+}
@@ -0,0 +1,93 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cruder/internal/model"
+)
+
+func loadTestPolicy(t *testing.T) *Policy {
+	t.Helper()
+	policy, err := Load(filepath.Join("..", "..", "policy.yaml"))
+	if err != nil {
+		t.Fatalf("expected the shipped policy to load, got %v", err)
+	}
+	return policy
+}
+
+func TestAuthorize_Roles(t *testing.T) {
+	enforcer := NewEnforcer(loadTestPolicy(t), slog.New(slog.DiscardHandler))
+
+	viewer := &model.Principal{Subject: "alice", Method: model.AuthMethodJWT, Roles: []string{RoleViewer}}
+	editor := &model.Principal{Subject: "bob", Method: model.AuthMethodJWT, Roles: []string{RoleEditor, "superuser"}}
+	static := &model.Principal{Subject: "static", Method: model.AuthMethodAPIKey}
+	nobody := &model.Principal{Subject: "carol", Method: model.AuthMethodJWT}
+	service := &model.Principal{Subject: "carol", Method: model.AuthMethodAPIKey, Scopes: model.Scopes}
+
+	cases := []struct {
+		principal  *model.Principal
+		permission string
+		self       bool
+		allowed    bool
+	}{
+		{viewer, model.ScopeUsersRead, false, true},
+		{viewer, model.ScopeUsersWrite, false, false},
+		{editor, model.ScopeUsersWrite, false, true},
+		{editor, model.ScopeUsersDelete, false, false},
+		{static, model.ScopeUsersDelete, false, true},
+		{nobody, model.ScopeUsersRead, false, false},
+		{nobody, model.ScopeUsersWrite, true, true},
+		{nobody, model.ScopeUsersDelete, true, false},
+		{service, model.ScopeUsersWrite, true, false},
+	}
+	for _, tc := range cases {
+		err := enforcer.Authorize(context.Background(), tc.principal, tc.permission, tc.self)
+		if tc.allowed && err != nil {
+			t.Errorf("%s %s (self=%v): expected to be allowed, got %v", tc.principal.Subject, tc.permission, tc.self, err)
+		}
+		if !tc.allowed && !errors.Is(err, model.ErrPermissionDenied) {
+			t.Errorf("%s %s (self=%v): expected ErrPermissionDenied, got %v", tc.principal.Subject, tc.permission, tc.self, err)
+		}
+	}
+}
+
+func TestAuthorizeField_OnlyAdminChangesUsername(t *testing.T) {
+	var logs bytes.Buffer
+	enforcer := NewEnforcer(loadTestPolicy(t), slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	editor := &model.Principal{Subject: "bob", Method: model.AuthMethodJWT, Roles: []string{RoleEditor}}
+	admin := &model.Principal{Subject: "root", Method: model.AuthMethodJWT, Roles: []string{RoleAdmin}}
+
+	if err := enforcer.AuthorizeField(context.Background(), editor, "users", "email", true); err != nil {
+		t.Errorf("expected unrestricted field to be allowed, got %v", err)
+	}
+	if err := enforcer.AuthorizeField(context.Background(), admin, "users", "username", false); err != nil {
+		t.Errorf("expected admin to change username, got %v", err)
+	}
+	if err := enforcer.AuthorizeField(context.Background(), editor, "users", "username", true); !errors.Is(err, model.ErrPermissionDenied) {
+		t.Errorf("expected ErrPermissionDenied, got %v", err)
+	}
+
+	if !strings.Contains(logs.String(), `"audit.outcome":"denied"`) || !strings.Contains(logs.String(), `"field":"username"`) {
+		t.Errorf("expected the denial to be audit logged, got %s", logs.String())
+	}
+}
+
+func TestLoad_RejectsUnknownRoles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	policy := "roles:\n  viewer:\n    permissions: [users:read]\nbindings:\n  - subject: api_key:reporting\n    roles: [auditor]\n"
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "auditor") {
+		t.Errorf("expected unknown role error, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package rbac
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+	// RoleSelf is granted implicitly to every caller acting on its own
+	// record and is never bound to a subject.
+	RoleSelf = "self"
+)
+
+// AnyPermission in a role's permissions grants every permission.
+const AnyPermission = "*"
+
+type Policy struct {
+	Roles map[string]Role `yaml:"roles"`
+	// Fields restricts who may change a field, keyed by "<resource>.<field>"
+	// and listing the roles that may. Fields without a rule are unrestricted.
+	Fields   map[string][]string `yaml:"fields"`
+	Bindings []Binding           `yaml:"bindings"`
+}
+
+type Role struct {
+	Permissions []string `yaml:"permissions"`
+}
+
+// Binding assigns roles to a subject written as "<method>:<subject>", such
+// as "api_key:reporting" or "jwt:5f0c...".
+type Binding struct {
+	Subject string   `yaml:"subject"`
+	Roles   []string `yaml:"roles"`
+}
+
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (p *Policy) validate() error {
+	known := func(role string) bool {
+		_, ok := p.Roles[role]
+		return ok
+	}
+	for key, roles := range p.Fields {
+		if _, _, ok := strings.Cut(key, "."); !ok {
+			return fmt.Errorf("policy field %q must be written as <resource>.<field>", key)
+		}
+		for _, role := range roles {
+			if !known(role) {
+				return fmt.Errorf("policy field %q references unknown role %q", key, role)
+			}
+		}
+	}
+	for _, binding := range p.Bindings {
+		if _, _, ok := strings.Cut(binding.Subject, ":"); !ok {
+			return fmt.Errorf("policy binding subject %q must be written as <method>:<subject>", binding.Subject)
+		}
+		for _, role := range binding.Roles {
+			if role == RoleSelf {
+				return fmt.Errorf("policy binding %q cannot assign the implicit %q role", binding.Subject, RoleSelf)
+			}
+			if !known(role) {
+				return fmt.Errorf("policy binding %q references unknown role %q", binding.Subject, role)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"cruder/internal/model"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, COALESCE(tenant, ''), expires_at, last_used_at, revoked_at, rotated_from, created_at`
+
+type APIKeyRepository interface {
+	List(ctx context.Context) ([]model.APIKey, error)
+	GetByID(ctx context.Context, id int) (*model.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+	Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	Rotate(ctx context.Context, id int, key *model.APIKey, oldExpiresAt time.Time) (*model.APIKey, error)
+	Revoke(ctx context.Context, id int, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db     *DBRouter
+	logger *slog.Logger
+	opts   Options
+}
+
+func NewAPIKeyRepository(db *DBRouter, logger *slog.Logger, opts Options) APIKeyRepository {
+	return &apiKeyRepository{db: db, logger: logger, opts: opts}
+}
+
+func (r *apiKeyRepository) reader(ctx context.Context) executor {
+	return newInstrumentedExecutor(r.db.Reader(ctx), "api_keys", r.logger, r.opts)
+}
+
+func (r *apiKeyRepository) writer(ctx context.Context) executor {
+	return newInstrumentedExecutor(r.db.Writer(ctx), "api_keys", r.logger, r.opts)
+}
+
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*model.APIKey, error) {
+	var k model.APIKey
+	var rotatedFrom sql.NullInt64
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.KeyHash, pq.Array(&k.Scopes), &k.Tenant, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &rotatedFrom, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	if rotatedFrom.Valid {
+		id := int(rotatedFrom.Int64)
+		k.RotatedFrom = &id
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]model.APIKey, error) {
+	rows, err := r.reader(ctx).QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id int) (*model.APIKey, error) {
+	k, err := scanAPIKey(r.reader(ctx).QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return k, err
+}
+
+// GetByPrefix reads from the primary so a key that was just created, rotated
+// or revoked is never judged against a lagging replica.
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	ctx = WithPrimary(ctx)
+	k, err := scanAPIKey(r.reader(ctx).QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return k, err
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	return r.insert(ctx, r.writer(ctx), key)
+}
+
+func (r *apiKeyRepository) insert(ctx context.Context, exec executor, key *model.APIKey) (*model.APIKey, error) {
+	return scanAPIKey(exec.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, tenant, expires_at, rotated_from)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		RETURNING `+apiKeyColumns,
+		key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.Tenant, key.ExpiresAt, key.RotatedFrom))
+}
+
+// Rotate inserts the replacement key and shortens the old key's lifetime to
+// the overlap window in one transaction.
+func (r *apiKeyRepository) Rotate(ctx context.Context, id int, key *model.APIKey, oldExpiresAt time.Time) (*model.APIKey, error) {
+	db := r.db.Writer(ctx)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	exec := newTxExecutor(tx, db, "api_keys", r.logger, r.opts)
+	result, err := exec.ExecContext(ctx, `
+		UPDATE api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1 AND revoked_at IS NULL`,
+		id, oldExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	created, err := r.insert(ctx, exec, key)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	result, err := r.writer(ctx).ExecContext(ctx, `UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`, id, at)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// TouchLastUsed writes to the primary without going through Writer: the
+// timestamp is bookkeeping nobody reads back, so it must not pin the
+// request or its session to the primary.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	exec := newInstrumentedExecutor(r.db.Primary(), "api_keys", r.logger, r.opts)
+	_, err := exec.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	return err
+}
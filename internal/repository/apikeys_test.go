@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAPIKeyRepository_GetByPrefixReadsPrimary(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+
+	router := NewDBRouter(primary, []*sql.DB{replica}, RouterOptions{CheckInterval: time.Hour})
+	defer router.Close()
+	repo := NewAPIKeyRepository(router, slog.New(slog.DiscardHandler), Options{})
+
+	primaryMock.ExpectQuery("SELECT .+ FROM api_keys WHERE prefix = \\$1").
+		WithArgs("0123456789ab").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "prefix", "key_hash", "scopes", "tenant", "expires_at", "last_used_at", "revoked_at", "rotated_from", "created_at"}).
+			AddRow(1, "reporting", "0123456789ab", "hash", "{users:read}", "", nil, nil, nil, nil, time.Now()))
+
+	key, err := repo.GetByPrefix(context.Background(), "0123456789ab")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if key == nil || key.Name != "reporting" {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAPIKeyRepository_TouchLastUsedKeepsReplicaReads(t *testing.T) {
+	primary, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer primary.Close()
+	replica, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+
+	router := NewDBRouter(primary, []*sql.DB{replica}, RouterOptions{CheckInterval: time.Hour, StickyWindow: time.Minute})
+	defer router.Close()
+	repo := NewAPIKeyRepository(router, slog.New(slog.DiscardHandler), Options{})
+
+	primaryMock.ExpectExec("UPDATE api_keys SET last_used_at = \\$2 WHERE id = \\$1").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := WithConsistency(context.Background(), "session-1")
+	if err := repo.TouchLastUsed(ctx, 1, time.Now()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if router.Reader(ctx) != replica {
+		t.Error("expected the request to keep reading from the replica")
+	}
+	if router.Reader(WithConsistency(context.Background(), "session-1")) != replica {
+		t.Error("expected the session to keep reading from the replica")
+	}
+}
@@ -1,40 +1,87 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type DatabaseConnection interface {
 	DB() *sql.DB
+	Replicas() []*sql.DB
 	Close() error
 }
 
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
 type PostgresConnection struct {
-	db *sql.DB
+	db       *sql.DB
+	replicas []*sql.DB
 }
 
 func (p *PostgresConnection) DB() *sql.DB {
 	return p.db
 }
 
+func (p *PostgresConnection) Replicas() []*sql.DB {
+	return p.replicas
+}
+
 func (p *PostgresConnection) Close() error {
-	return p.db.Close()
+	errs := []error{p.db.Close()}
+	for _, replica := range p.replicas {
+		errs = append(errs, replica.Close())
+	}
+	return errors.Join(errs...)
 }
 
-func NewPostgresConnection(dsn string) (*PostgresConnection, error) {
-	db, err := sql.Open("postgres", dsn)
+func NewPostgresConnection(ctx context.Context, dsn string, logger *slog.Logger, pool PoolOptions, retry RetryOptions, replicaDSNs ...string) (*PostgresConnection, error) {
+	db, err := openPostgres(dsn, pool)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
+	if err := pingWithRetry(ctx, db, logger, retry); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &PostgresConnection{
+	conn := &PostgresConnection{
 		db: db,
-	}, nil
+	}
+
+	for i, replicaDSN := range replicaDSNs {
+		replica, err := openPostgres(replicaDSN, pool)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i, err)
+		}
+		conn.replicas = append(conn.replicas, replica)
+	}
+
+	return conn, nil
+}
+
+func openPostgres(dsn string, pool PoolOptions) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	return db, nil
 }
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"cruder/internal/model"
+	"database/sql"
+)
+
+type DatabaseRepository interface {
+	Stats() []model.PoolStats
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (int64, error)
+}
+
+type databaseRepository struct {
+	db *DBRouter
+}
+
+func NewDatabaseRepository(db *DBRouter) DatabaseRepository {
+	return &databaseRepository{db: db}
+}
+
+func (r *databaseRepository) Stats() []model.PoolStats {
+	return r.db.Stats()
+}
+
+func (r *databaseRepository) Ping(ctx context.Context) error {
+	return r.db.Primary().PingContext(ctx)
+}
+
+// MigrationVersion mirrors how goose resolves the current version: the most
+// recent row for each version wins, so rolled back versions are skipped.
+func (r *databaseRepository) MigrationVersion(ctx context.Context) (int64, error) {
+	rows, err := r.db.Primary().QueryContext(ctx, `SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	skipped := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var applied bool
+		if err := rows.Scan(&version, &applied); err != nil {
+			return 0, err
+		}
+		if skipped[version] {
+			continue
+		}
+		if applied {
+			return version, nil
+		}
+		skipped[version] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return 0, sql.ErrNoRows
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"cruder/internal/logging"
+	"cruder/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlExecutor is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type sqlExecutor interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type executor interface {
+	QueryContext(ctx context.Context, query string, args ...any) (rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close() error
+}
+
+type row interface {
+	Scan(dest ...any) error
+}
+
+// instrumentedExecutor records a client span and statistics for every
+// statement it runs, logs statements that fail or exceed the slow query
+// threshold and, when enabled, explains slow statements. Plans always run on
+// pool: the explain runs in the background and must never share a
+// transaction's connection.
+type instrumentedExecutor struct {
+	db     sqlExecutor
+	pool   *sql.DB
+	table  string
+	logger *slog.Logger
+	opts   Options
+}
+
+func newInstrumentedExecutor(db *sql.DB, table string, logger *slog.Logger, opts Options) executor {
+	return &instrumentedExecutor{db: db, pool: db, table: table, logger: logger, opts: opts}
+}
+
+// newTxExecutor runs statements in tx and explains slow ones on pool, the
+// handle tx was started from.
+func newTxExecutor(tx *sql.Tx, pool *sql.DB, table string, logger *slog.Logger, opts Options) executor {
+	return &instrumentedExecutor{db: tx, pool: pool, table: table, logger: logger, opts: opts}
+}
+
+func (e *instrumentedExecutor) QueryContext(ctx context.Context, query string, args ...any) (rows, error) {
+	stmt := e.start(ctx, query, args)
+	r, err := e.db.QueryContext(stmt.ctx, e.annotate(ctx, query), args...)
+	if err != nil {
+		stmt.finish(0, err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: r, stmt: stmt}, nil
+}
+
+func (e *instrumentedExecutor) QueryRowContext(ctx context.Context, query string, args ...any) row {
+	stmt := e.start(ctx, query, args)
+	return &instrumentedRow{row: e.db.QueryRowContext(stmt.ctx, e.annotate(ctx, query), args...), stmt: stmt}
+}
+
+func (e *instrumentedExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt := e.start(ctx, query, args)
+	result, err := e.db.ExecContext(stmt.ctx, e.annotate(ctx, query), args...)
+	var affected int64
+	if err == nil {
+		affected, _ = result.RowsAffected()
+	}
+	stmt.finish(affected, err)
+	return result, err
+}
+
+type statement struct {
+	e         *instrumentedExecutor
+	ctx       context.Context
+	span      trace.Span
+	query     string
+	args      []any
+	sanitized string
+	started   time.Time
+}
+
+func (e *instrumentedExecutor) start(ctx context.Context, query string, args []any) *statement {
+	sanitized := tracing.SanitizeStatement(query)
+	operation, _, _ := strings.Cut(sanitized, " ")
+	operation = strings.ToUpper(operation)
+
+	ctx, span := tracing.Tracer().Start(ctx, operation+" "+e.table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.collection.name", e.table),
+			attribute.String("db.statement", sanitized),
+		),
+	)
+	return &statement{e: e, ctx: ctx, span: span, query: query, args: args, sanitized: sanitized, started: time.Now()}
+}
+
+func (s *statement) finish(rowCount int64, err error) {
+	duration := time.Since(s.started)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+
+	e := s.e
+	slow := e.opts.SlowQueryThreshold > 0 && duration >= e.opts.SlowQueryThreshold
+	explain := false
+	if e.opts.Stats != nil {
+		firstSlow := e.opts.Stats.record(s.sanitized, duration, rowCount, err, slow)
+		explain = firstSlow && e.opts.ExplainSlowQueries
+	}
+
+	s.span.SetAttributes(attribute.Int64("db.response.returned_rows", rowCount))
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+		e.logger.ErrorContext(s.ctx, "sql statement failed", "db.statement", s.sanitized, "error", err)
+	}
+	s.span.End()
+
+	if slow {
+		e.logger.WarnContext(s.ctx, "slow sql statement",
+			"db.statement", s.sanitized,
+			"db.args", redactArgs(s.args),
+			"db.rows", rowCount,
+			"duration_ms", duration.Milliseconds(),
+		)
+	}
+	if explain {
+		go s.explain()
+	}
+}
+
+// explain logs the query plan of a slow statement. Plain EXPLAIN plans the
+// statement without executing it, so this is safe for writes as well.
+func (s *statement) explain() {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), 5*time.Second)
+	defer cancel()
+
+	r, err := s.e.pool.QueryContext(ctx, "EXPLAIN "+s.query, s.args...)
+	if err != nil {
+		s.e.logger.WarnContext(ctx, "failed to explain slow sql statement", "db.statement", s.sanitized, "error", err)
+		return
+	}
+	defer r.Close()
+
+	var plan []string
+	for r.Next() {
+		var line string
+		if err := r.Scan(&line); err != nil {
+			break
+		}
+		plan = append(plan, line)
+	}
+	s.e.logger.InfoContext(ctx, "slow sql statement plan", "db.statement", s.sanitized, "plan", strings.Join(plan, "\n"))
+}
+
+// redactArgs keeps only the type of each argument so slow query logs never
+// carry user data.
+func redactArgs(args []any) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = fmt.Sprintf("$%d=<%T>", i+1, arg)
+	}
+	return redacted
+}
+
+type instrumentedRows struct {
+	*sql.Rows
+	stmt  *statement
+	count int64
+	done  bool
+}
+
+func (r *instrumentedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	return false
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.done {
+		r.done = true
+		r.stmt.finish(r.count, r.Rows.Err())
+	}
+	return err
+}
+
+type instrumentedRow struct {
+	row  *sql.Row
+	stmt *statement
+}
+
+func (r *instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	var count int64
+	if err == nil {
+		count = 1
+	}
+	r.stmt.finish(count, err)
+	return err
+}
+
+// annotate prefixes query with the request id of ctx when SQL comments are
+// enabled. Request ids are validated by the middleware, but anything that
+// could close the comment is rejected here as well.
+func (e *instrumentedExecutor) annotate(ctx context.Context, query string) string {
+	if !e.opts.SQLComments {
+		return query
+	}
+	requestID := logging.RequestID(ctx)
+	if requestID == "" || strings.ContainsAny(requestID, "*/\n") {
+		return query
+	}
+	return "/* request_id=" + requestID + " */ " + query
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cruder/internal/logging"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newMockExecutor(t *testing.T, opts Options) (executor, sqlmock.Sqlmock, *bytes.Buffer) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	var logs bytes.Buffer
+	return newInstrumentedExecutor(db, "users", slog.New(slog.NewJSONHandler(&logs, nil)), opts), mock, &logs
+}
+
+func TestInstrumentedExecutor_RecordsStatements(t *testing.T) {
+	stats := NewQueryStats()
+	exec, mock, logs := newMockExecutor(t, Options{Stats: stats})
+
+	mock.ExpectQuery("SELECT id FROM users WHERE tenant_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectQuery("SELECT id FROM users WHERE username = \\$1").
+		WithArgs("jdoe").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("DELETE FROM users WHERE uuid = \\$1").
+		WithArgs("42").
+		WillReturnError(errors.New("connection reset"))
+
+	rows, err := exec.QueryContext(context.Background(), "SELECT id FROM users WHERE tenant_id = $1", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	var id int64
+	if err := exec.QueryRowContext(context.Background(), "SELECT id FROM users WHERE username = $1", "jdoe").Scan(&id); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows to reach the caller, got %v", err)
+	}
+	if _, err := exec.ExecContext(context.Background(), "DELETE FROM users WHERE uuid = $1", "42"); err == nil {
+		t.Fatal("expected the exec error to reach the caller")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	byStatement := make(map[string][2]int64)
+	for _, s := range stats.Snapshot() {
+		byStatement[s.Statement] = [2]int64{s.Rows, s.Errors}
+	}
+	want := map[string][2]int64{
+		"SELECT id FROM users WHERE tenant_id = $1": {2, 0},
+		"SELECT id FROM users WHERE username = $1":  {0, 0},
+		"DELETE FROM users WHERE uuid = $1":         {0, 1},
+	}
+	for statement, counts := range want {
+		if byStatement[statement] != counts {
+			t.Errorf("%s: expected rows and errors %v, got %v", statement, counts, byStatement[statement])
+		}
+	}
+	if !strings.Contains(logs.String(), "sql statement failed") || strings.Count(logs.String(), "sql statement failed") != 1 {
+		t.Errorf("expected only the failed exec to be logged, got %s", logs.String())
+	}
+}
+
+func TestInstrumentedExecutor_SlowStatementsAndComments(t *testing.T) {
+	exec, mock, logs := newMockExecutor(t, Options{SlowQueryThreshold: time.Nanosecond, SQLComments: true})
+
+	mock.ExpectExec(regexp.QuoteMeta("/* request_id=req-1 */ UPDATE users SET email = $1")).
+		WithArgs("jdoe@example.com").
+		WillDelayFor(time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET email = $1")).
+		WithArgs("jdoe@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	if _, err := exec.ExecContext(ctx, "UPDATE users SET email = $1", "jdoe@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// A request id that could close the comment is left out.
+	ctx = logging.WithRequestID(context.Background(), "x */ DROP TABLE users; /*")
+	if _, err := exec.ExecContext(ctx, "UPDATE users SET email = $1", "jdoe@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(logs.String(), "slow sql statement") || !strings.Contains(logs.String(), `"$1=<string>"`) {
+		t.Errorf("expected a slow statement log with redacted args, got %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "jdoe@example.com") {
+		t.Errorf("expected argument values to stay out of the logs, got %s", logs.String())
+	}
+}
+
+// lockedBuffer lets a test read logs written by the background explain.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestInstrumentedExecutor_ExplainsTransactionStatementsOnPool(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mock.MatchExpectationsInOrder(false)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET email = $1")).
+		WithArgs("jdoe@example.com").
+		WillDelayFor(time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("EXPLAIN UPDATE users SET email = $1")).
+		WithArgs("jdoe@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow("Update on users"))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs lockedBuffer
+	opts := Options{SlowQueryThreshold: time.Nanosecond, ExplainSlowQueries: true, Stats: NewQueryStats()}
+	exec := newTxExecutor(tx, db, "users", slog.New(slog.NewJSONHandler(&logs, nil)), opts)
+	if _, err := exec.ExecContext(context.Background(), "UPDATE users SET email = $1", "jdoe@example.com"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(logs.String(), "slow sql statement plan") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !strings.Contains(logs.String(), "Update on users") {
+		t.Fatalf("expected the plan to be explained on the pool after commit, got %s", logs.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	stats := NewQueryStats()
+
+	if stats.record("SELECT 1", 10*time.Millisecond, 1, nil, false) {
+		t.Error("expected a fast execution not to be reported as first slow")
+	}
+	if !stats.record("SELECT 1", 30*time.Millisecond, 1, nil, true) {
+		t.Error("expected the first slow execution to be reported")
+	}
+	if stats.record("SELECT 1", 20*time.Millisecond, 0, errors.New("boom"), true) {
+		t.Error("expected only the first slow execution to be reported")
+	}
+	stats.record("SELECT 2", 100*time.Millisecond, 5, nil, false)
+
+	snapshot := stats.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Statement != "SELECT 2" {
+		t.Fatalf("expected statements ordered by total time, got %+v", snapshot)
+	}
+	got := snapshot[1]
+	if got.Calls != 3 || got.Errors != 1 || got.SlowCalls != 2 || got.Rows != 2 || got.TotalMs != 60 || got.MeanMs != 20 || got.MaxMs != 30 {
+		t.Errorf("unexpected statistics %+v", got)
+	}
+
+	stats.Reset()
+	if snapshot := stats.Snapshot(); len(snapshot) != 0 {
+		t.Errorf("expected no statistics after a reset, got %+v", snapshot)
+	}
+}
+
+func TestRedactArgs(t *testing.T) {
+	got := redactArgs([]any{"secret@example.com", int64(42), nil, []byte("x")})
+	want := []string{"$1=<string>", "$2=<int64>", "$3=<<nil>>", "$4=<[]uint8>"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"cruder/internal/metrics"
+	"cruder/internal/model"
+	"time"
+)
+
+type instrumentedUserRepository struct {
+	next UserRepository
+}
+
+func NewInstrumentedUserRepository(next UserRepository) UserRepository {
+	return &instrumentedUserRepository{next: next}
+}
+
+func (r *instrumentedUserRepository) GetAll(ctx context.Context) (users []model.User, err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "GetAll", start, err) }(time.Now())
+	return r.next.GetAll(ctx)
+}
+
+func (r *instrumentedUserRepository) GetByUsername(ctx context.Context, username string) (user *model.User, err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "GetByUsername", start, err) }(time.Now())
+	return r.next.GetByUsername(ctx, username)
+}
+
+func (r *instrumentedUserRepository) GetByID(ctx context.Context, id int64) (user *model.User, err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "GetByID", start, err) }(time.Now())
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *instrumentedUserRepository) GetByUUID(ctx context.Context, uuid string) (user *model.User, err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "GetByUUID", start, err) }(time.Now())
+	return r.next.GetByUUID(ctx, uuid)
+}
+
+func (r *instrumentedUserRepository) Create(ctx context.Context, user *model.User) (created *model.User, err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "Create", start, err) }(time.Now())
+	return r.next.Create(ctx, user)
+}
+
+func (r *instrumentedUserRepository) Update(ctx context.Context, uuid string, user *model.User) (updated *model.User, err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "Update", start, err) }(time.Now())
+	return r.next.Update(ctx, uuid, user)
+}
+
+func (r *instrumentedUserRepository) Delete(ctx context.Context, uuid string) (err error) {
+	defer func(start time.Time) { metrics.ObserveQuery("users", "Delete", start, err) }(time.Now())
+	return r.next.Delete(ctx, uuid)
+}
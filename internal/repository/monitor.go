@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthMonitor pings the primary in the background so requests can be
+// rejected quickly while the database is unreachable instead of piling up.
+type HealthMonitor struct {
+	db       *sql.DB
+	logger   *slog.Logger
+	interval time.Duration
+	healthy  atomic.Bool
+
+	mu      sync.RWMutex
+	lastErr error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewHealthMonitor(db *sql.DB, logger *slog.Logger, interval time.Duration) *HealthMonitor {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	m := &HealthMonitor{
+		db:       db,
+		logger:   logger,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	m.healthy.Store(true)
+
+	go m.run()
+	return m
+}
+
+func (m *HealthMonitor) Healthy() bool {
+	return m.healthy.Load()
+}
+
+func (m *HealthMonitor) Err() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastErr
+}
+
+func (m *HealthMonitor) Check(ctx context.Context) error {
+	err := m.db.PingContext(ctx)
+
+	m.mu.Lock()
+	m.lastErr = err
+	m.mu.Unlock()
+
+	if wasHealthy := m.healthy.Swap(err == nil); wasHealthy != (err == nil) {
+		if err != nil {
+			m.logger.Error("database became unavailable", "error", err)
+		} else {
+			m.logger.Info("database connection recovered")
+		}
+	}
+	return err
+}
+
+func (m *HealthMonitor) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+			m.Check(ctx)
+			cancel()
+		}
+	}
+}
+
+func (m *HealthMonitor) Close() error {
+	select {
+	case <-m.done:
+		return nil
+	default:
+	}
+	close(m.stop)
+	<-m.done
+	return nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"cruder/internal/metrics"
+	"cruder/internal/model"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type consistencyKey struct{}
+
+type consistency struct {
+	sessionID    string
+	forcePrimary bool
+	lastWrite    atomic.Int64
+}
+
+// WithConsistency attaches read-your-writes state to ctx. Writes made with the
+// returned context pin subsequent reads in the same request, and in the same
+// session when sessionID is not empty, to the primary for the sticky window.
+func WithConsistency(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, &consistency{sessionID: sessionID})
+}
+
+// WithPrimary forces every read made with the returned context to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	state := &consistency{forcePrimary: true}
+	if parent, ok := ctx.Value(consistencyKey{}).(*consistency); ok {
+		state.sessionID = parent.sessionID
+		state.lastWrite.Store(parent.lastWrite.Load())
+	}
+	return context.WithValue(ctx, consistencyKey{}, state)
+}
+
+type RouterOptions struct {
+	CheckInterval time.Duration
+	StickyWindow  time.Duration
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// DBRouter sends writes to the primary and spreads reads across healthy
+// replicas, falling back to the primary when none are available.
+type DBRouter struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+	opts     RouterOptions
+
+	mu       sync.Mutex
+	sessions map[string]time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewDBRouter(primary *sql.DB, replicas []*sql.DB, opts RouterOptions) *DBRouter {
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = 5 * time.Second
+	}
+
+	r := &DBRouter{
+		primary:  primary,
+		opts:     opts,
+		sessions: make(map[string]time.Time),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, db := range replicas {
+		rep := &replica{db: db}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+
+	if len(r.replicas) == 0 {
+		close(r.done)
+		return r
+	}
+
+	r.checkReplicas()
+	go r.run()
+	return r
+}
+
+func (r *DBRouter) Primary() *sql.DB {
+	return r.primary
+}
+
+func (r *DBRouter) Reader(ctx context.Context) *sql.DB {
+	if len(r.replicas) == 0 || r.pinnedToPrimary(ctx) {
+		return r.primary
+	}
+
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return r.primary
+}
+
+func (r *DBRouter) Writer(ctx context.Context) *sql.DB {
+	if len(r.replicas) == 0 || r.opts.StickyWindow <= 0 {
+		return r.primary
+	}
+
+	now := time.Now()
+	if state, ok := ctx.Value(consistencyKey{}).(*consistency); ok {
+		state.lastWrite.Store(now.UnixNano())
+		if state.sessionID != "" {
+			r.mu.Lock()
+			r.sessions[state.sessionID] = now
+			r.mu.Unlock()
+		}
+	}
+	return r.primary
+}
+
+func (r *DBRouter) pinnedToPrimary(ctx context.Context) bool {
+	state, ok := ctx.Value(consistencyKey{}).(*consistency)
+	if !ok {
+		return false
+	}
+	if state.forcePrimary {
+		return true
+	}
+	if r.opts.StickyWindow <= 0 {
+		return false
+	}
+
+	if last := state.lastWrite.Load(); last != 0 && time.Since(time.Unix(0, last)) < r.opts.StickyWindow {
+		return true
+	}
+	if state.sessionID == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	last, ok := r.sessions[state.sessionID]
+	r.mu.Unlock()
+	return ok && time.Since(last) < r.opts.StickyWindow
+}
+
+func (r *DBRouter) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.checkReplicas()
+			r.expireSessions()
+		}
+	}
+}
+
+func (r *DBRouter) checkReplicas() {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func(rep *replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), r.opts.CheckInterval/2)
+			defer cancel()
+			rep.healthy.Store(rep.db.PingContext(ctx) == nil)
+		}(rep)
+	}
+	wg.Wait()
+}
+
+func (r *DBRouter) expireSessions() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, last := range r.sessions {
+		if time.Since(last) >= r.opts.StickyWindow {
+			delete(r.sessions, id)
+		}
+	}
+}
+
+// Close stops the replica health checks. The connections themselves are
+// owned and closed by the PostgresConnection they came from.
+func (r *DBRouter) Close() error {
+	select {
+	case <-r.done:
+		return nil
+	default:
+	}
+	close(r.stop)
+	<-r.done
+	return nil
+}
+
+// RegisterMetrics exposes pool statistics for the primary and every replica.
+func (r *DBRouter) RegisterMetrics() {
+	metrics.RegisterDB("primary", r.primary)
+	for i, rep := range r.replicas {
+		metrics.RegisterDB(fmt.Sprintf("replica-%d", i), rep.db)
+	}
+}
+
+func (r *DBRouter) Stats() []model.PoolStats {
+	stats := []model.PoolStats{poolStats("primary", true, r.primary)}
+	for i, rep := range r.replicas {
+		stats = append(stats, poolStats(fmt.Sprintf("replica-%d", i), rep.healthy.Load(), rep.db))
+	}
+	return stats
+}
+
+func poolStats(name string, healthy bool, db *sql.DB) model.PoolStats {
+	s := db.Stats()
+	return model.PoolStats{
+		Name:               name,
+		Healthy:            healthy,
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newMockDB(t *testing.T, healthy bool) *sql.DB {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if healthy {
+		mock.ExpectPing()
+	} else {
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	}
+	return db
+}
+
+func newTestRouter(t *testing.T, replicas []*sql.DB, opts RouterOptions) (*DBRouter, *sql.DB) {
+	t.Helper()
+	primary := newMockDB(t, true)
+	opts.CheckInterval = time.Hour
+	router := NewDBRouter(primary, replicas, opts)
+	t.Cleanup(func() { router.Close() })
+	return router, primary
+}
+
+func TestDBRouter_RoundRobin(t *testing.T) {
+	a, b := newMockDB(t, true), newMockDB(t, true)
+	router, primary := newTestRouter(t, []*sql.DB{a, b}, RouterOptions{})
+
+	reads := map[*sql.DB]int{}
+	var last *sql.DB
+	for i := 0; i < 4; i++ {
+		db := router.Reader(context.Background())
+		if db == last {
+			t.Errorf("read %d: expected reads to alternate between replicas", i)
+		}
+		reads[db]++
+		last = db
+	}
+	if reads[a] != 2 || reads[b] != 2 || reads[primary] != 0 {
+		t.Errorf("expected reads spread over both replicas, got %v", reads)
+	}
+	if router.Writer(context.Background()) != primary {
+		t.Error("expected writes to go to the primary")
+	}
+}
+
+func TestDBRouter_SkipsUnhealthyReplicas(t *testing.T) {
+	down, up := newMockDB(t, false), newMockDB(t, true)
+	router, _ := newTestRouter(t, []*sql.DB{down, up}, RouterOptions{})
+
+	for i := 0; i < 4; i++ {
+		if router.Reader(context.Background()) != up {
+			t.Fatalf("read %d: expected the healthy replica", i)
+		}
+	}
+
+	allDown, primary := newTestRouter(t, []*sql.DB{newMockDB(t, false)}, RouterOptions{})
+	if allDown.Reader(context.Background()) != primary {
+		t.Error("expected reads to fall back to the primary without a healthy replica")
+	}
+}
+
+func TestDBRouter_RequestStickiness(t *testing.T) {
+	replica := newMockDB(t, true)
+	router, primary := newTestRouter(t, []*sql.DB{replica}, RouterOptions{StickyWindow: 50 * time.Millisecond})
+
+	ctx := WithConsistency(context.Background(), "")
+	if router.Reader(ctx) != replica {
+		t.Error("expected reads before a write to use the replica")
+	}
+	router.Writer(ctx)
+	if router.Reader(ctx) != primary {
+		t.Error("expected reads after a write in the same request to use the primary")
+	}
+	if router.Reader(WithConsistency(context.Background(), "")) != replica {
+		t.Error("expected other requests to keep using the replica")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if router.Reader(ctx) != replica {
+		t.Error("expected the request to return to the replica after the sticky window")
+	}
+}
+
+func TestDBRouter_SessionStickiness(t *testing.T) {
+	replica := newMockDB(t, true)
+	router, primary := newTestRouter(t, []*sql.DB{replica}, RouterOptions{StickyWindow: 50 * time.Millisecond})
+
+	router.Writer(WithConsistency(context.Background(), "session-a"))
+
+	if router.Reader(WithConsistency(context.Background(), "session-a")) != primary {
+		t.Error("expected the next request of the session to read from the primary")
+	}
+	if router.Reader(WithConsistency(context.Background(), "session-b")) != replica {
+		t.Error("expected other sessions to read from the replica")
+	}
+	if router.Reader(WithPrimary(context.Background())) != primary {
+		t.Error("expected WithPrimary to force the primary")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if router.Reader(WithConsistency(context.Background(), "session-a")) != replica {
+		t.Error("expected the session to return to the replica after the sticky window")
+	}
+	router.expireSessions()
+	router.mu.Lock()
+	sessions := len(router.sessions)
+	router.mu.Unlock()
+	if sessions != 0 {
+		t.Errorf("expected expired sessions to be dropped, %d left", sessions)
+	}
+}
+
+func TestDBRouter_WithoutStickyWindow(t *testing.T) {
+	replica := newMockDB(t, true)
+	router, _ := newTestRouter(t, []*sql.DB{replica}, RouterOptions{})
+
+	ctx := WithConsistency(context.Background(), "session-a")
+	router.Writer(ctx)
+	if router.Reader(ctx) != replica {
+		t.Error("expected reads to stay on the replica without a sticky window")
+	}
+}
@@ -1,13 +1,47 @@
 package repository
 
-import "database/sql"
+import (
+	"log/slog"
+	"time"
+)
+
+type Options struct {
+	// SQLComments prefixes every statement with a comment carrying the
+	// request id so it shows up in pg_stat_activity and the server logs.
+	SQLComments bool
+
+	// SlowQueryThreshold logs statements that take at least this long.
+	SlowQueryThreshold time.Duration
+	// ExplainSlowQueries logs the plan of each statement the first time it
+	// is slow. It should stay off in production.
+	ExplainSlowQueries bool
+	// RowLevelSecurity sets app.tenant_id in a transaction around tenant
+	// scoped statements, so the users table's row-level security policy
+	// backs up the tenant filter in each query.
+	RowLevelSecurity bool
+
+	// Stats collects per-statement statistics; NewRepository creates one
+	// when it is nil.
+	Stats *QueryStats
+}
 
 type Repository struct {
-	Users UserRepository
+	Users      UserRepository
+	Database   DatabaseRepository
+	APIKeys    APIKeyRepository
+	Tenants    TenantRepository
+	QueryStats *QueryStats
 }
 
-func NewRepository(db *sql.DB) *Repository {
+func NewRepository(db *DBRouter, logger *slog.Logger, opts Options) *Repository {
+	if opts.Stats == nil {
+		opts.Stats = NewQueryStats()
+	}
 	return &Repository{
-		Users: NewUserRepository(db),
+		Users:      NewInstrumentedUserRepository(NewUserRepository(db, logger, opts)),
+		Database:   NewDatabaseRepository(db),
+		APIKeys:    NewAPIKeyRepository(db, logger, opts),
+		Tenants:    NewTenantRepository(db, logger, opts),
+		QueryStats: opts.Stats,
 	}
 }
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type RetryOptions struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxWait         time.Duration
+	Multiplier      float64
+	Jitter          float64
+}
+
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	interval := float64(o.InitialInterval) * math.Pow(o.Multiplier, float64(attempt))
+	if o.MaxInterval > 0 && interval > float64(o.MaxInterval) {
+		interval = float64(o.MaxInterval)
+	}
+	if o.Jitter > 0 {
+		interval *= 1 + o.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(interval)
+}
+
+func pingWithRetry(ctx context.Context, db *sql.DB, logger *slog.Logger, opts RetryOptions) error {
+	if opts.InitialInterval <= 0 {
+		return db.PingContext(ctx)
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = 1
+	}
+
+	deadline := time.Now().Add(opts.MaxWait)
+	for attempt := 0; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		wait := opts.backoff(attempt)
+		if opts.MaxWait > 0 && time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt+1, err)
+		}
+		logger.Warn("database not ready", "attempt", attempt+1, "retry_in", wait.Round(time.Millisecond).String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"cruder/internal/model"
+)
+
+// QueryStats aggregates latency, row counts and errors per sanitized
+// statement across every executor that shares it.
+type QueryStats struct {
+	mu         sync.Mutex
+	statements map[string]*statementStats
+}
+
+type statementStats struct {
+	calls     int64
+	errors    int64
+	slowCalls int64
+	rows      int64
+	total     time.Duration
+	max       time.Duration
+}
+
+func NewQueryStats() *QueryStats {
+	return &QueryStats{statements: make(map[string]*statementStats)}
+}
+
+// record adds one execution and reports whether it was the first slow one
+// for the statement.
+func (s *QueryStats) record(statement string, duration time.Duration, rows int64, err error, slow bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, ok := s.statements[statement]
+	if !ok {
+		stats = &statementStats{}
+		s.statements[statement] = stats
+	}
+	stats.calls++
+	stats.rows += rows
+	stats.total += duration
+	stats.max = max(stats.max, duration)
+	if err != nil {
+		stats.errors++
+	}
+	if slow {
+		stats.slowCalls++
+	}
+	return slow && stats.slowCalls == 1
+}
+
+// Snapshot returns the statistics ordered by total time spent, highest first.
+func (s *QueryStats) Snapshot() []model.QueryStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make([]model.QueryStats, 0, len(s.statements))
+	for statement, stats := range s.statements {
+		snapshot = append(snapshot, model.QueryStats{
+			Statement: statement,
+			Calls:     stats.calls,
+			Errors:    stats.errors,
+			SlowCalls: stats.slowCalls,
+			Rows:      stats.rows,
+			TotalMs:   float64(stats.total.Microseconds()) / 1000,
+			MeanMs:    float64(stats.total.Microseconds()) / 1000 / float64(stats.calls),
+			MaxMs:     float64(stats.max.Microseconds()) / 1000,
+		})
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].TotalMs > snapshot[j].TotalMs
+	})
+	return snapshot
+}
+
+func (s *QueryStats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statements = make(map[string]*statementStats)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"cruder/internal/model"
+	"database/sql"
+	"log/slog"
+)
+
+const tenantColumns = `id, slug, name, created_at`
+
+type TenantRepository interface {
+	List(ctx context.Context) ([]model.Tenant, error)
+	GetBySlug(ctx context.Context, slug string) (*model.Tenant, error)
+	Create(ctx context.Context, tenant *model.Tenant) (*model.Tenant, error)
+}
+
+type tenantRepository struct {
+	db     *DBRouter
+	logger *slog.Logger
+	opts   Options
+}
+
+func NewTenantRepository(db *DBRouter, logger *slog.Logger, opts Options) TenantRepository {
+	return &tenantRepository{db: db, logger: logger, opts: opts}
+}
+
+func (r *tenantRepository) reader(ctx context.Context) executor {
+	return newInstrumentedExecutor(r.db.Reader(ctx), "tenants", r.logger, r.opts)
+}
+
+func (r *tenantRepository) writer(ctx context.Context) executor {
+	return newInstrumentedExecutor(r.db.Writer(ctx), "tenants", r.logger, r.opts)
+}
+
+func scanTenant(row interface{ Scan(dest ...any) error }) (*model.Tenant, error) {
+	var t model.Tenant
+	if err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *tenantRepository) List(ctx context.Context) ([]model.Tenant, error) {
+	rows, err := r.reader(ctx).QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []model.Tenant
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+func (r *tenantRepository) GetBySlug(ctx context.Context, slug string) (*model.Tenant, error) {
+	t, err := scanTenant(r.reader(ctx).QueryRowContext(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE slug = $1`, slug))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return t, err
+}
+
+func (r *tenantRepository) Create(ctx context.Context, tenant *model.Tenant) (*model.Tenant, error) {
+	return scanTenant(r.writer(ctx).QueryRowContext(ctx, `
+		INSERT INTO tenants (slug, name)
+		VALUES ($1, $2)
+		RETURNING `+tenantColumns,
+		tenant.Slug, tenant.Name))
+}
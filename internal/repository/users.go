@@ -3,54 +3,75 @@ package repository
 import (
 	"context"
 	"cruder/internal/model"
+	"cruder/internal/tenancy"
 	"database/sql"
+	"log/slog"
+	"strconv"
 )
 
+const userColumns = `id, uuid, username, email, full_name, created_at, updated_at`
+
 type UserRepository interface {
-	GetAll() ([]model.User, error)
-	GetByUsername(username string) (*model.User, error)
-	GetByID(id int64) (*model.User, error)
-	GetByUUID(uuid string) (*model.User, error)
-	Create(user *model.User) (*model.User, error)
-	Update(uuid string, user *model.User) (*model.User, error)
-	Delete(uuid string) error
+	GetAll(ctx context.Context) ([]model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByID(ctx context.Context, id int64) (*model.User, error)
+	GetByUUID(ctx context.Context, uuid string) (*model.User, error)
+	Create(ctx context.Context, user *model.User) (*model.User, error)
+	Update(ctx context.Context, uuid string, user *model.User) (*model.User, error)
+	Delete(ctx context.Context, uuid string) error
 }
 
+// userRepository scopes every statement to the tenant carried by the
+// context and fails with model.ErrTenantRequired when there is none.
 type userRepository struct {
-	db *sql.DB
+	db     *DBRouter
+	logger *slog.Logger
+	opts   Options
 }
 
-func NewUserRepository(db *sql.DB) UserRepository {
-	return &userRepository{db: db}
+func NewUserRepository(db *DBRouter, logger *slog.Logger, opts Options) UserRepository {
+	return &userRepository{db: db, logger: logger, opts: opts}
 }
 
-func (r *userRepository) GetAll() ([]model.User, error) {
-	rows, err := r.db.QueryContext(context.Background(), `SELECT id, uuid, username, email, full_name, created_at, updated_at FROM users`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (r *userRepository) read(ctx context.Context, fn func(exec executor, tenantID int) error) error {
+	return r.scoped(ctx, r.db.Reader(ctx), fn)
+}
 
-	var users []model.User
-	for rows.Next() {
-		var u model.User
-		if err := rows.Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
-			return nil, err
-		}
-		users = append(users, u)
+func (r *userRepository) write(ctx context.Context, fn func(exec executor, tenantID int) error) error {
+	return r.scoped(ctx, r.db.Writer(ctx), fn)
+}
+
+// scoped runs fn with the context's tenant. With row-level security enabled
+// it runs inside a transaction that sets app.tenant_id, so Postgres enforces
+// the tenant even if a statement forgot to filter on it.
+func (r *userRepository) scoped(ctx context.Context, db *sql.DB, fn func(exec executor, tenantID int) error) error {
+	tenant, ok := tenancy.FromContext(ctx)
+	if !ok {
+		return model.ErrTenantRequired
+	}
+	if !r.opts.RowLevelSecurity {
+		return fn(newInstrumentedExecutor(db, "users", r.logger, r.opts), tenant.ID)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	return users, nil
+	exec := newTxExecutor(tx, db, "users", r.logger, r.opts)
+	if _, err := exec.ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, strconv.Itoa(tenant.ID)); err != nil {
+		return err
+	}
+	if err := fn(exec, tenant.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *userRepository) GetByUsername(username string) (*model.User, error) {
+func scanUser(row interface{ Scan(dest ...any) error }) (*model.User, error) {
 	var u model.User
-	if err := r.db.QueryRowContext(context.Background(), `SELECT id, uuid, username, email, full_name, created_at, updated_at FROM users WHERE username = $1`, username).
-		Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
+	if err := row.Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -59,74 +80,97 @@ func (r *userRepository) GetByUsername(username string) (*model.User, error) {
 	return &u, nil
 }
 
-func (r *userRepository) GetByID(id int64) (*model.User, error) {
-	var u model.User
-	if err := r.db.QueryRowContext(context.Background(), `SELECT id, uuid, username, email, full_name, created_at, updated_at FROM users WHERE id = $1`, id).
-		Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+func (r *userRepository) GetAll(ctx context.Context) ([]model.User, error) {
+	var users []model.User
+	err := r.read(ctx, func(exec executor, tenantID int) error {
+		rows, err := exec.QueryContext(ctx, `SELECT `+userColumns+` FROM users WHERE tenant_id = $1`, tenantID)
+		if err != nil {
+			return err
 		}
-		return nil, err
-	}
-	return &u, nil
-}
+		defer rows.Close()
 
-func (r *userRepository) GetByUUID(uuid string) (*model.User, error) {
-	var u model.User
-	if err := r.db.QueryRowContext(context.Background(), `SELECT id, uuid, username, email, full_name, created_at, updated_at FROM users WHERE uuid = $1`, uuid).
-		Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+		for rows.Next() {
+			u, err := scanUser(rows)
+			if err != nil {
+				return err
+			}
+			users = append(users, *u)
 		}
+		return rows.Err()
+	})
+	if err != nil {
 		return nil, err
 	}
-	return &u, nil
+	return users, nil
 }
 
-func (r *userRepository) Create(user *model.User) (*model.User, error) {
-	var u model.User
-	if err := r.db.QueryRowContext(context.Background(), `
-		INSERT INTO users (username, email, full_name) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, uuid, username, email, full_name, created_at, updated_at`,
-		user.Username, user.Email, user.FullName).
-		Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
-		return nil, err
-	}
-	return &u, nil
+func (r *userRepository) getOne(ctx context.Context, column string, value any) (*model.User, error) {
+	var user *model.User
+	err := r.read(ctx, func(exec executor, tenantID int) error {
+		var err error
+		user, err = scanUser(exec.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE tenant_id = $1 AND `+column+` = $2`, tenantID, value))
+		return err
+	})
+	return user, err
 }
 
-func (r *userRepository) Update(uuid string, user *model.User) (*model.User, error) {
-	var u model.User
-	if err := r.db.QueryRowContext(context.Background(), `
-		UPDATE users 
-		SET username = COALESCE(NULLIF($2, ''), username),
-		    email = COALESCE(NULLIF($3, ''), email),
-		    full_name = COALESCE(NULLIF($4, ''), full_name),
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE uuid = $1
-		RETURNING id, uuid, username, email, full_name, created_at, updated_at`,
-		uuid, user.Username, user.Email, user.FullName).
-		Scan(&u.ID, &u.UUID, &u.Username, &u.Email, &u.FullName, &u.CreatedAt, &u.UpdatedAt); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &u, nil
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	return r.getOne(ctx, "username", username)
 }
 
-func (r *userRepository) Delete(uuid string) error {
-	result, err := r.db.ExecContext(context.Background(), `DELETE FROM users WHERE uuid = $1`, uuid)
-	if err != nil {
+func (r *userRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	return r.getOne(ctx, "id", id)
+}
+
+func (r *userRepository) GetByUUID(ctx context.Context, uuid string) (*model.User, error) {
+	return r.getOne(ctx, "uuid", uuid)
+}
+
+func (r *userRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	var created *model.User
+	err := r.write(ctx, func(exec executor, tenantID int) error {
+		var err error
+		created, err = scanUser(exec.QueryRowContext(ctx, `
+			INSERT INTO users (tenant_id, username, email, full_name)
+			VALUES ($1, $2, $3, $4)
+			RETURNING `+userColumns,
+			tenantID, user.Username, user.Email, user.FullName))
 		return err
-	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	})
+	return created, err
+}
+
+func (r *userRepository) Update(ctx context.Context, uuid string, user *model.User) (*model.User, error) {
+	var updated *model.User
+	err := r.write(ctx, func(exec executor, tenantID int) error {
+		var err error
+		updated, err = scanUser(exec.QueryRowContext(ctx, `
+			UPDATE users
+			SET username = COALESCE(NULLIF($3, ''), username),
+			    email = COALESCE(NULLIF($4, ''), email),
+			    full_name = COALESCE(NULLIF($5, ''), full_name),
+			    updated_at = CURRENT_TIMESTAMP
+			WHERE tenant_id = $1 AND uuid = $2
+			RETURNING `+userColumns,
+			tenantID, uuid, user.Username, user.Email, user.FullName))
 		return err
-	}
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
-	}
-	return nil
+	})
+	return updated, err
+}
+
+func (r *userRepository) Delete(ctx context.Context, uuid string) error {
+	return r.write(ctx, func(exec executor, tenantID int) error {
+		result, err := exec.ExecContext(ctx, `DELETE FROM users WHERE tenant_id = $1 AND uuid = $2`, tenantID, uuid)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
 }
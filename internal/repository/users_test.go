@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+	"cruder/internal/tenancy"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var userRowColumns = []string{"id", "uuid", "username", "email", "full_name", "created_at", "updated_at"}
+
+func newMockUserRepository(t *testing.T, opts Options) (UserRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	router := NewDBRouter(db, nil, RouterOptions{})
+	return NewUserRepository(router, slog.New(slog.DiscardHandler), opts), mock
+}
+
+func userRow() *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(userRowColumns).AddRow(1, "42", "jdoe", "jdoe@example.com", "John Doe", now, now)
+}
+
+func TestUserRepository_ScopesStatementsToTenant(t *testing.T) {
+	repo, mock := newMockUserRepository(t, Options{})
+	ctx := tenancy.WithTenant(context.Background(), model.Tenant{ID: 7, Slug: "acme"})
+
+	mock.ExpectQuery("SELECT .+ FROM users WHERE tenant_id = \\$1$").
+		WithArgs(7).
+		WillReturnRows(userRow())
+	mock.ExpectQuery("SELECT .+ FROM users WHERE tenant_id = \\$1 AND uuid = \\$2").
+		WithArgs(7, "42").
+		WillReturnRows(userRow())
+	mock.ExpectQuery("INSERT INTO users \\(tenant_id, username, email, full_name\\)").
+		WithArgs(7, "jdoe", "jdoe@example.com", "John Doe").
+		WillReturnRows(userRow())
+	mock.ExpectQuery("UPDATE users .+ WHERE tenant_id = \\$1 AND uuid = \\$2").
+		WithArgs(7, "42", "", "new@example.com", "").
+		WillReturnRows(userRow())
+	mock.ExpectExec("DELETE FROM users WHERE tenant_id = \\$1 AND uuid = \\$2").
+		WithArgs(7, "42").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := repo.GetAll(ctx); err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if _, err := repo.GetByUUID(ctx, "42"); err != nil {
+		t.Fatalf("GetByUUID: %v", err)
+	}
+	if _, err := repo.Create(ctx, &model.User{Username: "jdoe", Email: "jdoe@example.com", FullName: "John Doe"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.Update(ctx, "42", &model.User{Email: "new@example.com"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := repo.Delete(ctx, "42"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUserRepository_RequiresTenant(t *testing.T) {
+	repo, mock := newMockUserRepository(t, Options{})
+
+	if _, err := repo.GetAll(context.Background()); !errors.Is(err, model.ErrTenantRequired) {
+		t.Errorf("expected ErrTenantRequired, got %v", err)
+	}
+	if err := repo.Delete(context.Background(), "42"); !errors.Is(err, model.ErrTenantRequired) {
+		t.Errorf("expected ErrTenantRequired, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUserRepository_RowLevelSecuritySetsTenant(t *testing.T) {
+	repo, mock := newMockUserRepository(t, Options{RowLevelSecurity: true})
+	ctx := tenancy.WithTenant(context.Background(), model.Tenant{ID: 7, Slug: "acme"})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT set_config\\('app.tenant_id', \\$1, true\\)").
+		WithArgs("7").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT .+ FROM users WHERE tenant_id = \\$1 AND username = \\$2").
+		WithArgs(7, "jdoe").
+		WillReturnRows(userRow())
+	mock.ExpectCommit()
+
+	user, err := repo.GetByUsername(ctx, "jdoe")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user == nil || user.Username != "jdoe" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Options struct {
+	ReadHeaderTimeout time.Duration
+	IdleTimeout       time.Duration
+	ShutdownDelay     time.Duration
+	ShutdownTimeout   time.Duration
+	// TLS serves HTTPS instead of plain HTTP when set.
+	TLS *tls.Config
+}
+
+type Server struct {
+	http *http.Server
+	opts Options
+}
+
+func New(addr string, handler http.Handler, opts Options) *Server {
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 30 * time.Second
+	}
+	return &Server{
+		http: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadHeaderTimeout: opts.ReadHeaderTimeout,
+			IdleTimeout:       opts.IdleTimeout,
+			TLSConfig:         opts.TLS,
+		},
+		opts: opts,
+	}
+}
+
+// Run serves until ctx is cancelled and then drains in-flight requests.
+// onShutdown is called as soon as the shutdown starts so readiness can fail
+// while the listener keeps accepting traffic for ShutdownDelay, giving load
+// balancers time to stop routing to this instance.
+func (s *Server) Run(ctx context.Context, onShutdown func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if s.http.TLSConfig != nil {
+			errCh <- s.http.ListenAndServeTLS("", "")
+		} else {
+			errCh <- s.http.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutdown signal received, draining connections", "addr", s.http.Addr)
+	if onShutdown != nil {
+		onShutdown()
+	}
+
+	if s.opts.ShutdownDelay > 0 {
+		select {
+		case <-time.After(s.opts.ShutdownDelay):
+		case err := <-errCh:
+			return err
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		s.http.Close()
+		return err
+	}
+	slog.Info("server stopped", "addr", s.http.Addr)
+	return nil
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// MinVersion is "1.2" or "1.3"; empty means 1.2.
+	MinVersion string
+	// CipherSuites restricts the TLS 1.2 suites by their standard names.
+	// TLS 1.3 suites are not configurable.
+	CipherSuites []string
+	// ClientAuth is "none", "optional" or "require". Client certificates
+	// are verified against ClientCAFile whenever one is presented.
+	ClientAuth   string
+	ClientCAFile string
+	// ReloadInterval is how often the files are checked for rotation.
+	ReloadInterval time.Duration
+	Logger         *slog.Logger
+}
+
+// TLSReloader serves the certificate, key and client CA bundle from disk and
+// reloads them when they change, so rotated certificates are picked up
+// without a restart. A rotation that fails to load keeps the previous files.
+type TLSReloader struct {
+	opts       TLSOptions
+	base       *tls.Config
+	clientAuth tls.ClientAuthType
+
+	mu       sync.RWMutex
+	config   *tls.Config
+	optional *tls.Config
+	modTimes map[string]time.Time
+}
+
+func NewTLSReloader(opts TLSOptions) (*TLSReloader, error) {
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = 30 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	base := &tls.Config{MinVersion: tls.VersionTLS12, NextProtos: []string{"h2", "http/1.1"}}
+	switch opts.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		base.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported tls min version %q", opts.MinVersion)
+	}
+	if len(opts.CipherSuites) > 0 {
+		suites, err := cipherSuites(opts.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		base.CipherSuites = suites
+	}
+
+	r := &TLSReloader{opts: opts, base: base}
+	switch opts.ClientAuth {
+	case "", "none":
+		r.clientAuth = tls.NoClientCert
+	case "optional":
+		r.clientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unsupported tls client auth %q", opts.ClientAuth)
+	}
+	if r.clientAuth != tls.NoClientCert && opts.ClientCAFile == "" {
+		return nil, fmt.Errorf("tls client auth %q requires a client ca file", opts.ClientAuth)
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config returns the server's TLS configuration, which always hands out the
+// most recently loaded files.
+func (r *TLSReloader) Config() *tls.Config {
+	config := r.base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.config, nil
+	}
+	return config
+}
+
+// ManagementConfig is Config with client certificates optional at most, for
+// listeners such as the admin and debug ports that metrics scrapers and
+// probes reach without one. Certificates that are presented are still
+// verified.
+func (r *TLSReloader) ManagementConfig() *tls.Config {
+	config := r.base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return r.optional, nil
+	}
+	return config
+}
+
+// Run checks the files for changes every ReloadInterval until ctx is done.
+func (r *TLSReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !r.changed() {
+			continue
+		}
+		if err := r.load(); err != nil {
+			r.opts.Logger.Error("failed to reload tls files, keeping the previous ones", "error", err)
+			continue
+		}
+		r.opts.Logger.Info("reloaded tls files", "cert", r.opts.CertFile)
+	}
+}
+
+func (r *TLSReloader) files() []string {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+	return files
+}
+
+func (r *TLSReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			// A file being replaced may briefly be missing; retry next tick.
+			return false
+		}
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TLSReloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+	config.ClientAuth = r.clientAuth
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client ca file %s contains no certificates", r.opts.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+
+	optional := config.Clone()
+	if optional.ClientAuth == tls.RequireAndVerifyClientCert {
+		optional.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.optional = optional
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+func cipherSuites(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure tls cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	tls  tls.Certificate
+}
+
+func issue(t *testing.T, cn string, serial int64, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, tls: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+func writePEM(t *testing.T, dir string, c *testCert) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestTLSReloader_MutualTLSAndRotation(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "test ca", 1, nil, 0)
+	caFile := filepath.Join(dir, "ca.crt")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600)
+	certFile, keyFile := writePEM(t, dir, issue(t, "localhost", 2, ca, x509.ExtKeyUsageServerAuth))
+
+	reloader, err := NewTLSReloader(TLSOptions{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientAuth:   "require",
+		ClientCAFile: caFile,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}))
+	srv.TLS = reloader.Config()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+
+	resp, err := client(issue(t, "billing", 3, ca, x509.ExtKeyUsageClientAuth).tls).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected the handshake to succeed, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "billing" || resp.TLS.PeerCertificates[0].SerialNumber.Int64() != 2 {
+		t.Errorf("unexpected response %q from certificate %v", body, resp.TLS.PeerCertificates[0].SerialNumber)
+	}
+
+	if _, err := client().Get(srv.URL); err == nil {
+		t.Error("expected a client without a certificate to be refused")
+	}
+	other := issue(t, "other ca", 4, nil, 0)
+	if _, err := client(issue(t, "billing", 5, other, x509.ExtKeyUsageClientAuth).tls).Get(srv.URL); err == nil {
+		t.Error("expected a certificate from another CA to be refused")
+	}
+
+	writePEM(t, dir, issue(t, "localhost", 6, ca, x509.ExtKeyUsageServerAuth))
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	if !reloader.changed() {
+		t.Fatal("expected the rotation to be noticed")
+	}
+	if err := reloader.load(); err != nil {
+		t.Fatalf("expected the rotated files to load, got %v", err)
+	}
+	resp, err = client(issue(t, "billing", 7, ca, x509.ExtKeyUsageClientAuth).tls).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if serial := resp.TLS.PeerCertificates[0].SerialNumber.Int64(); serial != 6 {
+		t.Errorf("expected the rotated certificate, got serial %d", serial)
+	}
+}
+
+func TestTLSReloader_ManagementConfigMakesClientCertsOptional(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "test ca", 1, nil, 0)
+	caFile := filepath.Join(dir, "ca.crt")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600)
+	certFile, keyFile := writePEM(t, dir, issue(t, "localhost", 2, ca, x509.ExtKeyUsageServerAuth))
+
+	reloader, err := NewTLSReloader(TLSOptions{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientAuth:   "require",
+		ClientCAFile: caFile,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.VerifiedChains) > 0 {
+			io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+		}
+	}))
+	srv.TLS = reloader.ManagementConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+
+	resp, err := client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected a client without a certificate to connect, got %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = client(issue(t, "billing", 3, ca, x509.ExtKeyUsageClientAuth).tls).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "billing" {
+		t.Errorf("expected a presented certificate to be verified, got %q", body)
+	}
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cruder/internal/audit"
+	"cruder/internal/model"
+	"cruder/internal/repository"
+)
+
+const (
+	apiKeyPrefix  = "crd"
+	maxCachedKeys = 10000
+	staticKeyName = "static"
+	touchTimeout  = 2 * time.Second
+)
+
+type APIKeyService interface {
+	Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	Create(ctx context.Context, name, tenant string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error)
+	Rotate(ctx context.Context, id int, overlap time.Duration) (string, *model.APIKey, error)
+	Revoke(ctx context.Context, id int) error
+}
+
+type APIKeyOptions struct {
+	// StaticKey is the legacy single key from the configuration. It keeps
+	// working with every scope until the deployment has moved to named keys.
+	StaticKey string
+	// DatabaseKeys enables the named keys stored in the api_keys table.
+	DatabaseKeys bool
+	// CacheTTL bounds how long a verified or rejected key is remembered.
+	CacheTTL time.Duration
+	// LastUsedInterval throttles last_used_at updates per key.
+	LastUsedInterval time.Duration
+}
+
+type cachedKey struct {
+	key     *model.APIKey
+	expires time.Time
+}
+
+type apiKeyService struct {
+	repo    repository.APIKeyRepository
+	tenants repository.TenantRepository
+	logger  *slog.Logger
+	audit   *audit.Logger
+	opts    APIKeyOptions
+
+	mu      sync.Mutex
+	cache   map[string]cachedKey
+	touched map[int]time.Time
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, tenants repository.TenantRepository, logger *slog.Logger, opts APIKeyOptions) APIKeyService {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = time.Minute
+	}
+	if opts.LastUsedInterval <= 0 {
+		opts.LastUsedInterval = time.Minute
+	}
+	return &apiKeyService{
+		repo:    repo,
+		tenants: tenants,
+		logger:  logger,
+		audit:   audit.New(logger),
+		opts:    opts,
+		cache:   make(map[string]cachedKey),
+		touched: make(map[int]time.Time),
+	}
+}
+
+// hashAPIKey returns the hex SHA-256 of a raw key. Keys carry 256 bits of
+// randomness, so a fast hash is enough to make the stored value useless.
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*model.APIKey, error) {
+	if s.opts.StaticKey != "" && subtle.ConstantTimeCompare([]byte(rawKey), []byte(s.opts.StaticKey)) == 1 {
+		return &model.APIKey{Name: staticKeyName, Scopes: model.Scopes}, nil
+	}
+
+	if !s.opts.DatabaseKeys {
+		return nil, model.ErrInvalidAPIKey
+	}
+
+	// Malformed keys are rejected without a lookup and are not cached, so
+	// they cannot crowd verified keys out of the cache.
+	prefix, ok := model.APIKeyPrefix(rawKey)
+	if !ok {
+		return nil, model.ErrInvalidAPIKey
+	}
+
+	hash := hashAPIKey(rawKey)
+	now := time.Now()
+
+	key, cached := s.cached(hash, now)
+	if !cached {
+		var err error
+		key, err = s.lookup(ctx, prefix, hash)
+		if err != nil {
+			return nil, err
+		}
+		s.store(hash, key, now)
+	}
+
+	if key == nil {
+		return nil, model.ErrInvalidAPIKey
+	}
+	if key.Revoked() {
+		return nil, model.ErrRevokedAPIKey
+	}
+	if key.Expired(now) {
+		return nil, model.ErrExpiredAPIKey
+	}
+	s.touch(ctx, key.ID, now)
+	return key, nil
+}
+
+// lookup returns nil without an error when the key does not match, so the
+// rejection can be cached as well.
+func (s *apiKeyService) lookup(ctx context.Context, prefix, hash string) (*model.APIKey, error) {
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || subtle.ConstantTimeCompare([]byte(hash), []byte(key.KeyHash)) != 1 {
+		return nil, nil
+	}
+	return key, nil
+}
+
+func (s *apiKeyService) cached(hash string, now time.Time) (*model.APIKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[hash]
+	if !ok || now.After(entry.expires) {
+		return nil, false
+	}
+	return entry.key, true
+}
+
+func (s *apiKeyService) store(hash string, key *model.APIKey, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cache[hash]; !ok && len(s.cache) >= maxCachedKeys {
+		s.evict(now)
+	}
+	s.cache[hash] = cachedKey{key: key, expires: now.Add(s.opts.CacheTTL)}
+}
+
+// evict makes room for one entry, dropping an expired entry if there is one,
+// then a rejection, and only then a verified key.
+func (s *apiKeyService) evict(now time.Time) {
+	var rejected, verified string
+	for hash, entry := range s.cache {
+		if now.After(entry.expires) {
+			delete(s.cache, hash)
+			return
+		}
+		if entry.key == nil {
+			rejected = hash
+		} else {
+			verified = hash
+		}
+	}
+	if rejected != "" {
+		delete(s.cache, rejected)
+		return
+	}
+	delete(s.cache, verified)
+}
+
+// touch records the key as used at most once per LastUsedInterval, outside
+// of the request so authentication never waits on the write.
+func (s *apiKeyService) touch(ctx context.Context, id int, now time.Time) {
+	s.mu.Lock()
+	last, ok := s.touched[id]
+	if ok && now.Sub(last) < s.opts.LastUsedInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[id] = now
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), touchTimeout)
+		defer cancel()
+		if err := s.repo.TouchLastUsed(ctx, id, now); err != nil {
+			s.logger.WarnContext(ctx, "failed to record api key usage", "api_key_id", id, "error", err)
+		}
+	}()
+}
+
+// forget drops every cached verification of the key with the given id, so a
+// revocation takes effect immediately on this instance and within CacheTTL on
+// the others.
+func (s *apiKeyService) forget(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, entry := range s.cache {
+		if entry.key != nil && entry.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "api_key.list", audit.OutcomeSuccess, "count", len(keys))
+	return keys, nil
+}
+
+func (s *apiKeyService) Create(ctx context.Context, name, tenant string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error) {
+	rawKey, key, err := s.create(ctx, name, tenant, scopes, expiresAt)
+	if err != nil {
+		s.audit.Record(ctx, "api_key.create", audit.OutcomeFailure, "api_key.name", name, "error", err)
+		return "", nil, err
+	}
+	s.audit.Record(ctx, "api_key.create", audit.OutcomeSuccess,
+		"api_key.id", key.ID, "api_key.name", key.Name, "api_key.scopes", key.Scopes, "api_key.tenant", key.Tenant)
+	return rawKey, key, nil
+}
+
+func (s *apiKeyService) Rotate(ctx context.Context, id int, overlap time.Duration) (string, *model.APIKey, error) {
+	rawKey, key, err := s.rotate(ctx, id, overlap)
+	if err != nil {
+		s.audit.Record(ctx, "api_key.rotate", audit.OutcomeFailure, "api_key.id", id, "error", err)
+		return "", nil, err
+	}
+	s.forget(id)
+	s.audit.Record(ctx, "api_key.rotate", audit.OutcomeSuccess,
+		"api_key.id", key.ID, "api_key.name", key.Name, "api_key.rotated_from", id, "overlap", overlap.String())
+	return rawKey, key, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id int) error {
+	if err := s.repo.Revoke(ctx, id, time.Now()); err != nil {
+		s.audit.Record(ctx, "api_key.revoke", audit.OutcomeFailure, "api_key.id", id, "error", err)
+		return err
+	}
+	s.forget(id)
+	s.audit.Record(ctx, "api_key.revoke", audit.OutcomeSuccess, "api_key.id", id)
+	return nil
+}
+
+func (s *apiKeyService) rotate(ctx context.Context, id int, overlap time.Duration) (string, *model.APIKey, error) {
+	if overlap < 0 {
+		return "", nil, model.NewRuleValidationError("api_key_overlap_negative", "overlap must not be negative")
+	}
+	old, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	if old == nil || old.Revoked() {
+		return "", nil, sql.ErrNoRows
+	}
+
+	// The replacement inherits the old expiry, so an expired key cannot be
+	// rotated into a live one.
+	now := time.Now()
+	if old.Expired(now) {
+		return "", nil, model.NewRuleValidationError("api_key_expired", "expired keys cannot be rotated")
+	}
+
+	rawKey, key, err := newAPIKey(old.Name, old.Scopes, old.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	key.Tenant = old.Tenant
+	key.RotatedFrom = &old.ID
+
+	created, err := s.repo.Rotate(ctx, id, key, now.Add(overlap))
+	if err != nil {
+		return "", nil, err
+	}
+	return rawKey, created, nil
+}
+
+func (s *apiKeyService) create(ctx context.Context, name, tenant string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, model.NewRuleValidationError("api_key_name_required", "name is required")
+	}
+	if len(scopes) == 0 {
+		return "", nil, model.NewRuleValidationError("api_key_scopes_required", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(model.Scopes, scope) {
+			return "", nil, model.NewRuleValidationError("api_key_scope_unknown", fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, model.NewRuleValidationError("api_key_expiry_past", "expiry must be in the future")
+	}
+	if tenant != "" {
+		found, err := s.tenants.GetBySlug(ctx, tenant)
+		if err != nil {
+			return "", nil, err
+		}
+		if found == nil {
+			return "", nil, model.NewRuleValidationError("api_key_tenant_unknown", fmt.Sprintf("unknown tenant %q", tenant))
+		}
+	}
+
+	rawKey, key, err := newAPIKey(name, scopes, expiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+	key.Tenant = tenant
+	created, err := s.repo.Create(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return rawKey, created, nil
+}
+
+// newAPIKey generates a key of the form crd_<prefix>_<secret>. Only its hash
+// is kept; the raw key is returned to the caller once.
+func newAPIKey(name string, scopes []string, expiresAt *time.Time) (string, *model.APIKey, error) {
+	prefix, err := randomToken(model.APIKeyPrefixLength/2, hex.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", nil, err
+	}
+	rawKey := apiKeyPrefix + "_" + prefix + "_" + strings.ReplaceAll(secret, "_", "-")
+
+	return rawKey, &model.APIKey{
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+)
+
+type MockAPIKeyRepository struct {
+	mu      sync.Mutex
+	keys    map[string]*model.APIKey
+	lookups int
+}
+
+func newMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{keys: make(map[string]*model.APIKey)}
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []model.APIKey
+	for _, key := range m.keys {
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (m *MockAPIKeyRepository) GetByID(ctx context.Context, id int) (*model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.ID == id {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockAPIKeyRepository) Rotate(ctx context.Context, id int, key *model.APIKey, oldExpiresAt time.Time) (*model.APIKey, error) {
+	m.mu.Lock()
+	for _, old := range m.keys {
+		if old.ID == id {
+			old.ExpiresAt = &oldExpiresAt
+		}
+	}
+	m.mu.Unlock()
+	return m.Create(ctx, key)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.ID == id && key.RevokedAt == nil {
+			key.RevokedAt = &at
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *MockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups++
+	key, ok := m.keys[prefix]
+	if !ok {
+		return nil, nil
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	created := *key
+	created.ID = len(m.keys) + 1
+	m.keys[key.Prefix] = &created
+	return &created, nil
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	return nil
+}
+
+func TestAPIKeyAuthenticate_Success(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true})
+
+	rawKey, created, err := service.Create(context.Background(), "reporting", "", []string{model.ScopeUsersRead}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if created.KeyHash == rawKey {
+		t.Error("expected key to be stored hashed")
+	}
+
+	for i := 0; i < 3; i++ {
+		key, err := service.Authenticate(context.Background(), rawKey)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if key.Name != "reporting" || !key.HasScope(model.ScopeUsersRead) || key.HasScope(model.ScopeUsersWrite) {
+			t.Errorf("unexpected key %+v", key)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Errorf("expected verified key to be cached, got %d lookups", repo.lookups)
+	}
+}
+
+func TestAPIKeyAuthenticate_WrongSecret(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true})
+
+	rawKey, _, err := service.Create(context.Background(), "reporting", "", []string{model.ScopeUsersRead}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, candidate := range []string{rawKey + "x", rawKey[:len(rawKey)-1], "not-a-key", ""} {
+		if _, err := service.Authenticate(context.Background(), candidate); !errors.Is(err, model.ErrInvalidAPIKey) {
+			t.Errorf("expected ErrInvalidAPIKey for %q, got %v", candidate, err)
+		}
+	}
+}
+
+func TestAPIKeyAuthenticate_Expired(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true})
+
+	expiresAt := time.Now().Add(50 * time.Millisecond)
+	rawKey, _, err := service.Create(context.Background(), "temporary", "", []string{model.ScopeUsersRead}, &expiresAt)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), rawKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := service.Authenticate(context.Background(), rawKey); !errors.Is(err, model.ErrExpiredAPIKey) {
+		t.Errorf("expected ErrExpiredAPIKey, got %v", err)
+	}
+}
+
+func TestAPIKeyAuthenticate_StaticKey(t *testing.T) {
+	service := NewAPIKeyService(newMockAPIKeyRepository(), nil, testLogger, APIKeyOptions{StaticKey: "legacy"})
+
+	key, err := service.Authenticate(context.Background(), "legacy")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !key.HasScope(model.ScopeUsersDelete) {
+		t.Error("expected static key to have every scope")
+	}
+
+	if _, err := service.Authenticate(context.Background(), "crd_0123456789ab_def"); !errors.Is(err, model.ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey when database keys are disabled, got %v", err)
+	}
+}
+
+func TestAPIKeyCreate_UnknownScope(t *testing.T) {
+	service := NewAPIKeyService(newMockAPIKeyRepository(), nil, testLogger, APIKeyOptions{DatabaseKeys: true})
+
+	_, _, err := service.Create(context.Background(), "reporting", "", []string{"users:admin"}, nil)
+
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestAPIKeyRotate_OverlapWindow(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true})
+
+	oldKey, created, err := service.Create(context.Background(), "reporting", "", []string{model.ScopeUsersRead}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), oldKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	newKey, rotated, err := service.Rotate(context.Background(), created.ID, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rotated.RotatedFrom == nil || *rotated.RotatedFrom != created.ID || rotated.Name != "reporting" {
+		t.Errorf("unexpected rotated key %+v", rotated)
+	}
+
+	for _, rawKey := range []string{oldKey, newKey} {
+		if _, err := service.Authenticate(context.Background(), rawKey); err != nil {
+			t.Errorf("expected both keys to be valid during the overlap, got %v", err)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := service.Authenticate(context.Background(), oldKey); !errors.Is(err, model.ErrExpiredAPIKey) {
+		t.Errorf("expected old key to expire after the overlap, got %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), newKey); err != nil {
+		t.Errorf("expected new key to stay valid, got %v", err)
+	}
+}
+
+func TestAPIKeyRotate_ExpiredKey(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true})
+
+	expiresAt := time.Now().Add(20 * time.Millisecond)
+	_, created, err := service.Create(context.Background(), "reporting", "", []string{model.ScopeUsersRead}, &expiresAt)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	_, rotated, err := service.Rotate(context.Background(), created.ID, time.Minute)
+	var validationErr *model.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Rule != "api_key_expired" {
+		t.Errorf("expected api_key_expired validation error, got %v", err)
+	}
+	if rotated != nil {
+		t.Errorf("expected no replacement key, got %+v", rotated)
+	}
+}
+
+func TestAPIKeyRevoke_TakesEffectImmediately(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true, CacheTTL: time.Hour})
+
+	rawKey, created, err := service.Create(context.Background(), "reporting", "", []string{model.ScopeUsersRead}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), rawKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := service.Revoke(context.Background(), created.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := service.Authenticate(context.Background(), rawKey); !errors.Is(err, model.ErrRevokedAPIKey) {
+		t.Errorf("expected ErrRevokedAPIKey, got %v", err)
+	}
+	if err := service.Revoke(context.Background(), created.ID); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows for a revoked key, got %v", err)
+	}
+}
+
+func TestAPIKeyAuthenticate_MalformedKeysAreNotLookedUp(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true}).(*apiKeyService)
+
+	for _, candidate := range []string{"not-a-key", "crd_short_secret", "crd_0123456789AB_secret"} {
+		if _, err := service.Authenticate(context.Background(), candidate); !errors.Is(err, model.ErrInvalidAPIKey) {
+			t.Errorf("expected ErrInvalidAPIKey for %q, got %v", candidate, err)
+		}
+	}
+	if repo.lookups != 0 || len(service.cache) != 0 {
+		t.Errorf("expected malformed keys to be neither looked up nor cached, got %d lookups and %d cached", repo.lookups, len(service.cache))
+	}
+}
+
+func TestAPIKeyAuthenticate_SprayKeepsVerifiedKeysCached(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	service := NewAPIKeyService(repo, nil, testLogger, APIKeyOptions{DatabaseKeys: true, CacheTTL: time.Hour}).(*apiKeyService)
+
+	rawKey, _, err := service.Create(context.Background(), "reporting", "", []string{model.ScopeUsersRead}, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := service.Authenticate(context.Background(), rawKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i := 0; i < maxCachedKeys+100; i++ {
+		guess := fmt.Sprintf("crd_%012x_guess", i)
+		if _, err := service.Authenticate(context.Background(), guess); !errors.Is(err, model.ErrInvalidAPIKey) {
+			t.Fatalf("expected ErrInvalidAPIKey for %q, got %v", guess, err)
+		}
+	}
+	if len(service.cache) > maxCachedKeys {
+		t.Errorf("expected at most %d cached keys, got %d", maxCachedKeys, len(service.cache))
+	}
+
+	lookups := repo.lookups
+	if _, err := service.Authenticate(context.Background(), rawKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if repo.lookups != lookups {
+		t.Error("expected the verified key to stay cached during a spray of guesses")
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cruder/internal/model"
+	"cruder/internal/repository"
+)
+
+type DatabaseService interface {
+	Stats() []model.PoolStats
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
+	Migrations(ctx context.Context) (model.MigrationStatus, error)
+}
+
+// migrationStatusTTL bounds how stale the version served by Migrations may
+// be. The readiness check refreshes it, so public callers rarely query.
+const migrationStatusTTL = 30 * time.Second
+
+type databaseService struct {
+	repo            repository.DatabaseRepository
+	expectedVersion int64
+
+	mu        sync.Mutex
+	applied   int64
+	checkedAt time.Time
+}
+
+func NewDatabaseService(repo repository.DatabaseRepository, expectedVersion int64) DatabaseService {
+	return &databaseService{repo: repo, expectedVersion: expectedVersion}
+}
+
+func (s *databaseService) Stats() []model.PoolStats {
+	return s.repo.Stats()
+}
+
+func (s *databaseService) Ping(ctx context.Context) error {
+	return s.repo.Ping(ctx)
+}
+
+// CheckMigrations fails while the schema is behind the migrations this build
+// ships. A newer schema passes, so instances of the previous release stay
+// ready while a rolling deployment migrates ahead of them.
+func (s *databaseService) CheckMigrations(ctx context.Context) error {
+	if s.expectedVersion == 0 {
+		return nil
+	}
+	version, err := s.readMigrationVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if version < s.expectedVersion {
+		return fmt.Errorf("migration version %d, expected at least %d", version, s.expectedVersion)
+	}
+	return nil
+}
+
+// Migrations reports the applied version as last read, reading it again only
+// once it is older than migrationStatusTTL.
+func (s *databaseService) Migrations(ctx context.Context) (model.MigrationStatus, error) {
+	s.mu.Lock()
+	version, fresh := s.applied, !s.checkedAt.IsZero() && time.Since(s.checkedAt) < migrationStatusTTL
+	s.mu.Unlock()
+	if fresh {
+		return model.MigrationStatus{Applied: version, Expected: s.expectedVersion}, nil
+	}
+
+	version, err := s.readMigrationVersion(ctx)
+	if err != nil {
+		return model.MigrationStatus{}, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return model.MigrationStatus{Applied: version, Expected: s.expectedVersion}, nil
+}
+
+func (s *databaseService) readMigrationVersion(ctx context.Context) (int64, error) {
+	version, err := s.repo.MigrationVersion(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.applied, s.checkedAt = version, time.Now()
+	s.mu.Unlock()
+	return version, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+)
+
+type MockDatabaseRepository struct {
+	version int64
+	err     error
+	reads   int
+}
+
+func (m *MockDatabaseRepository) Stats() []model.PoolStats {
+	return nil
+}
+
+func (m *MockDatabaseRepository) Ping(ctx context.Context) error {
+	return m.err
+}
+
+func (m *MockDatabaseRepository) MigrationVersion(ctx context.Context) (int64, error) {
+	m.reads++
+	return m.version, m.err
+}
+
+func TestCheckMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int64
+		repo     *MockDatabaseRepository
+		wantErr  bool
+	}{
+		{name: "current", expected: 20251019120000, repo: &MockDatabaseRepository{version: 20251019120000}},
+		{name: "ahead during a rolling deployment", expected: 20251019120000, repo: &MockDatabaseRepository{version: 20251101090000}},
+		{name: "behind", expected: 20251019120000, repo: &MockDatabaseRepository{version: 20251001000000}, wantErr: true},
+		{name: "unreadable", expected: 20251019120000, repo: &MockDatabaseRepository{err: errors.New("connection refused")}, wantErr: true},
+		{name: "disabled", expected: 0, repo: &MockDatabaseRepository{err: errors.New("connection refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewDatabaseService(tt.repo, tt.expected).CheckMigrations(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMigrations_ServesCachedVersion(t *testing.T) {
+	repo := &MockDatabaseRepository{version: 20251019120000}
+	service := NewDatabaseService(repo, 20251019120000)
+
+	if err := service.CheckMigrations(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		status, err := service.Migrations(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if status.Applied != 20251019120000 || status.Expected != 20251019120000 {
+			t.Errorf("unexpected status %+v", status)
+		}
+	}
+	if repo.reads != 1 {
+		t.Errorf("expected the version checked for readiness to be reused, got %d reads", repo.reads)
+	}
+
+	service.(*databaseService).checkedAt = time.Now().Add(-migrationStatusTTL)
+	repo.version = 20251101090000
+	if status, _ := service.Migrations(context.Background()); status.Applied != 20251101090000 || repo.reads != 2 {
+		t.Errorf("expected a stale version to be read again, got %+v after %d reads", status, repo.reads)
+	}
+}
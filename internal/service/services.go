@@ -1,13 +1,23 @@
 package service
 
-import "cruder/internal/repository"
+import (
+	"log/slog"
+
+	"cruder/internal/repository"
+)
 
 type Service struct {
-	Users UserService
+	Users    UserService
+	Database DatabaseService
+	APIKeys  APIKeyService
+	Tenants  TenantService
 }
 
-func NewService(repos *repository.Repository) *Service {
+func NewService(repos *repository.Repository, migrationVersion int64, logger *slog.Logger, apiKeyOpts APIKeyOptions) *Service {
 	return &Service{
-		Users: NewUserService(repos.Users),
+		Users:    NewTracedUserService(NewUserService(repos.Users, logger)),
+		Database: NewDatabaseService(repos.Database, migrationVersion),
+		APIKeys:  NewAPIKeyService(repos.APIKeys, repos.Tenants, logger, apiKeyOpts),
+		Tenants:  NewTenantService(repos.Tenants, logger),
 	}
 }
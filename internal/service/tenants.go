@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"cruder/internal/audit"
+	"cruder/internal/model"
+	"cruder/internal/repository"
+	"cruder/pkg/validation"
+)
+
+const tenantCacheTTL = time.Minute
+
+type TenantService interface {
+	// Resolve returns the tenant with the given slug, or nil if there is
+	// none. Found tenants are cached, since it runs on every request.
+	Resolve(ctx context.Context, slug string) (*model.Tenant, error)
+	List(ctx context.Context) ([]model.Tenant, error)
+	Create(ctx context.Context, req *model.CreateTenantRequest) (*model.Tenant, error)
+}
+
+type cachedTenant struct {
+	tenant  model.Tenant
+	expires time.Time
+}
+
+type tenantService struct {
+	repo  repository.TenantRepository
+	audit *audit.Logger
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+func NewTenantService(repo repository.TenantRepository, logger *slog.Logger) TenantService {
+	return &tenantService{
+		repo:  repo,
+		audit: audit.New(logger),
+		cache: make(map[string]cachedTenant),
+	}
+}
+
+func (s *tenantService) Resolve(ctx context.Context, slug string) (*model.Tenant, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[slug]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return &entry.tenant, nil
+	}
+
+	if validation.ValidateTenantSlug(slug) != nil {
+		return nil, nil
+	}
+	tenant, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil || tenant == nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[slug] = cachedTenant{tenant: *tenant, expires: now.Add(tenantCacheTTL)}
+	s.mu.Unlock()
+	return tenant, nil
+}
+
+func (s *tenantService) List(ctx context.Context) ([]model.Tenant, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *tenantService) Create(ctx context.Context, req *model.CreateTenantRequest) (*model.Tenant, error) {
+	tenant, err := s.create(ctx, req)
+	if err != nil {
+		s.audit.Record(ctx, "tenant.create", audit.OutcomeFailure, "tenant.slug", req.Slug, "error", err)
+		return nil, err
+	}
+	s.audit.Record(ctx, "tenant.create", audit.OutcomeSuccess, "tenant.id", tenant.ID, "tenant.slug", tenant.Slug)
+	return tenant, nil
+}
+
+func (s *tenantService) create(ctx context.Context, req *model.CreateTenantRequest) (*model.Tenant, error) {
+	if err := validation.ValidateCreateTenantInput(req.Slug, req.Name); err != nil {
+		return nil, validationFailed(err)
+	}
+	existing, err := s.repo.GetBySlug(ctx, req.Slug)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, model.NewRuleValidationError("tenant_slug_taken", "tenant slug is already taken")
+	}
+	return s.repo.Create(ctx, &model.Tenant{Slug: req.Slug, Name: req.Name})
+}
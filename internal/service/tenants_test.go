@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cruder/internal/model"
+)
+
+type MockTenantRepository struct {
+	tenants map[string]*model.Tenant
+	lookups int
+}
+
+func (m *MockTenantRepository) List(ctx context.Context) ([]model.Tenant, error) {
+	var tenants []model.Tenant
+	for _, t := range m.tenants {
+		tenants = append(tenants, *t)
+	}
+	return tenants, nil
+}
+
+func (m *MockTenantRepository) GetBySlug(ctx context.Context, slug string) (*model.Tenant, error) {
+	m.lookups++
+	return m.tenants[slug], nil
+}
+
+func (m *MockTenantRepository) Create(ctx context.Context, tenant *model.Tenant) (*model.Tenant, error) {
+	created := *tenant
+	created.ID = len(m.tenants) + 1
+	m.tenants[tenant.Slug] = &created
+	return &created, nil
+}
+
+func TestTenantCreate_Validation(t *testing.T) {
+	repo := &MockTenantRepository{tenants: map[string]*model.Tenant{"default": {ID: 1, Slug: "default"}}}
+	service := NewTenantService(repo, testLogger)
+
+	for _, req := range []model.CreateTenantRequest{
+		{Slug: "", Name: "Empty"},
+		{Slug: "Sales Team", Name: "Sales"},
+		{Slug: "sales", Name: ""},
+		{Slug: "default", Name: "Taken"},
+	} {
+		var validationErr *model.ValidationError
+		if _, err := service.Create(context.Background(), &req); !errors.As(err, &validationErr) {
+			t.Errorf("expected validation error for %+v, got %v", req, err)
+		}
+	}
+
+	tenant, err := service.Create(context.Background(), &model.CreateTenantRequest{Slug: "sales", Name: "Sales"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tenant.ID == 0 || tenant.Slug != "sales" {
+		t.Errorf("unexpected tenant %+v", tenant)
+	}
+}
+
+func TestTenantResolve_CachesFoundTenants(t *testing.T) {
+	repo := &MockTenantRepository{tenants: map[string]*model.Tenant{"default": {ID: 1, Slug: "default"}}}
+	service := NewTenantService(repo, testLogger)
+
+	for i := 0; i < 3; i++ {
+		tenant, err := service.Resolve(context.Background(), "default")
+		if err != nil || tenant == nil || tenant.ID != 1 {
+			t.Fatalf("expected the default tenant, got %+v, %v", tenant, err)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Errorf("expected one lookup, got %d", repo.lookups)
+	}
+
+	if tenant, err := service.Resolve(context.Background(), "missing"); tenant != nil || err != nil {
+		t.Errorf("expected no tenant, got %+v, %v", tenant, err)
+	}
+}
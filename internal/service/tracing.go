@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	"cruder/internal/model"
+	"cruder/internal/tracing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracedUserService struct {
+	next UserService
+}
+
+func NewTracedUserService(next UserService) UserService {
+	return &tracedUserService{next: next}
+}
+
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, name)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *tracedUserService) GetAll(ctx context.Context) (users []model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetAll")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetAll(ctx)
+}
+
+func (s *tracedUserService) GetByUsername(ctx context.Context, username string) (user *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetByUsername")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetByUsername(ctx, username)
+}
+
+func (s *tracedUserService) GetByID(ctx context.Context, id int64) (user *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetByID")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *tracedUserService) GetByUUID(ctx context.Context, uuid string) (user *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetByUUID")
+	defer func() { endSpan(span, err) }()
+	return s.next.GetByUUID(ctx, uuid)
+}
+
+func (s *tracedUserService) Create(ctx context.Context, req *model.CreateUserRequest) (user *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.Create")
+	defer func() { endSpan(span, err) }()
+	return s.next.Create(ctx, req)
+}
+
+func (s *tracedUserService) Update(ctx context.Context, uuid string, req *model.UpdateUserRequest) (user *model.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.Update")
+	defer func() { endSpan(span, err) }()
+	return s.next.Update(ctx, uuid, req)
+}
+
+func (s *tracedUserService) Delete(ctx context.Context, uuid string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.Delete")
+	defer func() { endSpan(span, err) }()
+	return s.next.Delete(ctx, uuid)
+}
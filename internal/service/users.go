@@ -1,38 +1,44 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"cruder/internal/metrics"
 	"cruder/internal/model"
 	"cruder/internal/repository"
 	"cruder/pkg/validation"
 )
 
 type UserService interface {
-	GetAll() ([]model.User, error)
-	GetByUsername(username string) (*model.User, error)
-	GetByID(id int64) (*model.User, error)
-	GetByUUID(uuid string) (*model.User, error)
-	Create(req *model.CreateUserRequest) (*model.User, error)
-	Update(uuid string, req *model.UpdateUserRequest) (*model.User, error)
-	Delete(uuid string) error
+	GetAll(ctx context.Context) ([]model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	GetByID(ctx context.Context, id int64) (*model.User, error)
+	GetByUUID(ctx context.Context, uuid string) (*model.User, error)
+	Create(ctx context.Context, req *model.CreateUserRequest) (*model.User, error)
+	Update(ctx context.Context, uuid string, req *model.UpdateUserRequest) (*model.User, error)
+	Delete(ctx context.Context, uuid string) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo   repository.UserRepository
+	logger *slog.Logger
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo repository.UserRepository, logger *slog.Logger) UserService {
+	return &userService{repo: repo, logger: logger}
 }
 
-func (s *userService) GetAll() ([]model.User, error) {
-	return s.repo.GetAll()
+func (s *userService) GetAll(ctx context.Context) ([]model.User, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *userService) GetByUsername(username string) (*model.User, error) {
+func (s *userService) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	if err := validation.ValidateUsername(username); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
-	user, err := s.repo.GetByUsername(username)
+	user, err := s.repo.GetByUsername(ctx, username)
 	if err != nil {
 		return nil, err
 	}
@@ -42,11 +48,11 @@ func (s *userService) GetByUsername(username string) (*model.User, error) {
 	return user, nil
 }
 
-func (s *userService) GetByID(id int64) (*model.User, error) {
+func (s *userService) GetByID(ctx context.Context, id int64) (*model.User, error) {
 	if err := validation.ValidateID(id); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
-	user, err := s.repo.GetByID(id)
+	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +62,11 @@ func (s *userService) GetByID(id int64) (*model.User, error) {
 	return user, nil
 }
 
-func (s *userService) GetByUUID(uuid string) (*model.User, error) {
+func (s *userService) GetByUUID(ctx context.Context, uuid string) (*model.User, error) {
 	if err := validation.ValidateUUID(uuid); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
-	user, err := s.repo.GetByUUID(uuid)
+	user, err := s.repo.GetByUUID(ctx, uuid)
 	if err != nil {
 		return nil, err
 	}
@@ -70,43 +76,64 @@ func (s *userService) GetByUUID(uuid string) (*model.User, error) {
 	return user, nil
 }
 
-func (s *userService) Create(req *model.CreateUserRequest) (*model.User, error) {
+func (s *userService) Create(ctx context.Context, req *model.CreateUserRequest) (*model.User, error) {
 	if err := validation.ValidateCreateUserInput(req.Username, req.Email, req.FullName); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
 	user := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
 		FullName: req.FullName,
 	}
-	return s.repo.Create(user)
+	created, err := s.repo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	metrics.UserCreated()
+	s.logger.InfoContext(ctx, "user created", "user_uuid", created.UUID)
+	return created, nil
 }
 
-func (s *userService) Update(uuid string, req *model.UpdateUserRequest) (*model.User, error) {
+func (s *userService) Update(ctx context.Context, uuid string, req *model.UpdateUserRequest) (*model.User, error) {
 	if err := validation.ValidateUUID(uuid); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
 	if err := validation.ValidateUpdateUserInput(req.Username, req.Email, req.FullName); err != nil {
-		return nil, err
+		return nil, validationFailed(err)
 	}
 	user := &model.User{
 		Username: req.Username,
 		Email:    req.Email,
 		FullName: req.FullName,
 	}
-	updatedUser, err := s.repo.Update(uuid, user)
+	updatedUser, err := s.repo.Update(ctx, uuid, user)
 	if err != nil {
 		return nil, err
 	}
 	if updatedUser == nil {
 		return nil, model.NewValidationError("user not found")
 	}
+	metrics.UserUpdated()
+	s.logger.InfoContext(ctx, "user updated", "user_uuid", updatedUser.UUID)
 	return updatedUser, nil
 }
 
-func (s *userService) Delete(uuid string) error {
+func (s *userService) Delete(ctx context.Context, uuid string) error {
 	if err := validation.ValidateUUID(uuid); err != nil {
+		return validationFailed(err)
+	}
+	if err := s.repo.Delete(ctx, uuid); err != nil {
 		return err
 	}
-	return s.repo.Delete(uuid)
+	metrics.UserDeleted()
+	s.logger.InfoContext(ctx, "user deleted", "user_uuid", uuid)
+	return nil
+}
+
+func validationFailed(err error) error {
+	var validationErr *model.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Rule != "" {
+		metrics.ValidationFailed(validationErr.Rule)
+	}
+	return err
 }
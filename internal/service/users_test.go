@@ -1,14 +1,18 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log/slog"
 	"testing"
 	"time"
 
 	"cruder/internal/model"
 )
 
+var testLogger = slog.New(slog.DiscardHandler)
+
 type MockUserRepository struct {
 	getAllFunc        func() ([]model.User, error)
 	getByUsernameFunc func(username string) (*model.User, error)
@@ -19,31 +23,31 @@ type MockUserRepository struct {
 	deleteFunc        func(uuid string) error
 }
 
-func (m *MockUserRepository) GetAll() ([]model.User, error) {
+func (m *MockUserRepository) GetAll(ctx context.Context) ([]model.User, error) {
 	return m.getAllFunc()
 }
 
-func (m *MockUserRepository) GetByUsername(username string) (*model.User, error) {
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
 	return m.getByUsernameFunc(username)
 }
 
-func (m *MockUserRepository) GetByID(id int64) (*model.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
 	return m.getByIDFunc(id)
 }
 
-func (m *MockUserRepository) GetByUUID(uuid string) (*model.User, error) {
+func (m *MockUserRepository) GetByUUID(ctx context.Context, uuid string) (*model.User, error) {
 	return m.getByUUIDFunc(uuid)
 }
 
-func (m *MockUserRepository) Create(user *model.User) (*model.User, error) {
+func (m *MockUserRepository) Create(ctx context.Context, user *model.User) (*model.User, error) {
 	return m.createFunc(user)
 }
 
-func (m *MockUserRepository) Update(uuid string, user *model.User) (*model.User, error) {
+func (m *MockUserRepository) Update(ctx context.Context, uuid string, user *model.User) (*model.User, error) {
 	return m.updateFunc(uuid, user)
 }
 
-func (m *MockUserRepository) Delete(uuid string) error {
+func (m *MockUserRepository) Delete(ctx context.Context, uuid string) error {
 	return m.deleteFunc(uuid)
 }
 
@@ -75,9 +79,9 @@ func TestGetAll_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetAll()
+	result, err := service.GetAll(context.Background())
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -97,10 +101,10 @@ func TestGetAll_EmptyResult(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
 	// When: Calling GetAll
-	result, err := service.GetAll()
+	result, err := service.GetAll(context.Background())
 
 	// Then: Should return empty slice without error
 	if err != nil {
@@ -118,10 +122,10 @@ func TestGetAll_DatabaseError(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
 	// When: Calling GetAll
-	result, err := service.GetAll()
+	result, err := service.GetAll(context.Background())
 
 	// Then: Should return error
 	if err == nil {
@@ -152,9 +156,9 @@ func TestGetByUsername_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetByUsername("jdoe")
+	result, err := service.GetByUsername(context.Background(), "jdoe")
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -171,9 +175,9 @@ func TestGetByUsername_UserNotFound(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetByUsername("nonexistent")
+	result, err := service.GetByUsername(context.Background(), "nonexistent")
 
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -209,9 +213,9 @@ func TestGetByID_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetByID(1)
+	result, err := service.GetByID(context.Background(), 1)
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -228,9 +232,9 @@ func TestGetByID_UserNotFound(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetByID(999)
+	result, err := service.GetByID(context.Background(), 999)
 
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -263,9 +267,9 @@ func TestGetByUUID_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetByUUID("123e4567-e89b-12d3-a456-426614174000")
+	result, err := service.GetByUUID(context.Background(), "123e4567-e89b-12d3-a456-426614174000")
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -282,9 +286,9 @@ func TestGetByUUID_UserNotFound(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.GetByUUID("invalid-uuid")
+	result, err := service.GetByUUID(context.Background(), "invalid-uuid")
 
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -320,9 +324,9 @@ func TestCreate_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.Create(req)
+	result, err := service.Create(context.Background(), req)
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -348,9 +352,9 @@ func TestCreate_DatabaseError(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.Create(req)
+	result, err := service.Create(context.Background(), req)
 
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -387,9 +391,9 @@ func TestUpdate_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.Update(uuid, req)
+	result, err := service.Update(context.Background(), uuid, req)
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -414,9 +418,9 @@ func TestUpdate_UserNotFound(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.Update(uuid, req)
+	result, err := service.Update(context.Background(), uuid, req)
 
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -441,9 +445,9 @@ func TestUpdate_DatabaseError(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	result, err := service.Update(uuid, req)
+	result, err := service.Update(context.Background(), uuid, req)
 
 	if err == nil {
 		t.Error("expected error, got nil")
@@ -468,9 +472,9 @@ func TestDelete_Success(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
-	err := service.Delete(uuid)
+	err := service.Delete(context.Background(), uuid)
 
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
@@ -486,10 +490,10 @@ func TestDelete_UserNotFound(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
 	// When: Calling Delete with invalid UUID
-	err := service.Delete(uuid)
+	err := service.Delete(context.Background(), uuid)
 
 	// Then: Should return sql.ErrNoRows
 	if err == nil {
@@ -511,10 +515,10 @@ func TestDelete_DatabaseError(t *testing.T) {
 		},
 	}
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, testLogger)
 
 	// When: Calling Delete
-	err := service.Delete(uuid)
+	err := service.Delete(context.Background(), uuid)
 
 	// Then: Should return error
 	if err == nil {
@@ -0,0 +1,61 @@
+package signature
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const sweepInterval = time.Minute
+
+// MemoryNonceCache keeps nonces in process, so each replica only rejects
+// replays it has seen itself.
+type MemoryNonceCache struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryNonceCache() *MemoryNonceCache {
+	return &MemoryNonceCache{nonces: make(map[string]time.Time), lastSweep: time.Now(), now: time.Now}
+}
+
+func (c *MemoryNonceCache) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) >= sweepInterval {
+		for n, expires := range c.nonces {
+			if !now.Before(expires) {
+				delete(c.nonces, n)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	if expires, ok := c.nonces[nonce]; ok && now.Before(expires) {
+		return false, nil
+	}
+	c.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisNonceCache shares nonces between replicas, so a request replayed
+// against another replica is rejected too.
+type RedisNonceCache struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisNonceCache(client redis.UniversalClient, prefix string) *RedisNonceCache {
+	return &RedisNonceCache{client: client, prefix: prefix}
+}
+
+func (c *RedisNonceCache) Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.prefix+nonce, 1, ttl).Result()
+}
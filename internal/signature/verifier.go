@@ -0,0 +1,118 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"cruder/internal/model"
+	"cruder/pkg/signing"
+)
+
+const (
+	maxNonceLength      = 128
+	defaultMaxBodyBytes = 1 << 20
+)
+
+// Key is a shared secret and what requests signed with it may do.
+type Key struct {
+	ID     string
+	Secret []byte
+	Scopes []string
+	Roles  []string
+	Tenant string
+}
+
+// NonceCache remembers nonces until they expire. Add reports false when the
+// nonce was already present.
+type NonceCache interface {
+	Add(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+type Options struct {
+	// MaxSkew is how far a request's timestamp may be from the server's
+	// clock, in either direction.
+	MaxSkew time.Duration
+	// MaxBodyBytes caps the body read to check the signature, since it is
+	// held in memory; longer bodies fail with *http.MaxBytesError. It
+	// defaults to 1 MiB.
+	MaxBodyBytes int64
+}
+
+type Verifier struct {
+	keys   map[string]Key
+	nonces NonceCache
+	opts   Options
+	now    func() time.Time
+}
+
+func NewVerifier(keys []Key, nonces NonceCache, opts Options) *Verifier {
+	if opts.MaxSkew <= 0 {
+		opts.MaxSkew = 5 * time.Minute
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	v := &Verifier{keys: make(map[string]Key, len(keys)), nonces: nonces, opts: opts, now: time.Now}
+	for _, key := range keys {
+		v.keys[key.ID] = key
+	}
+	return v
+}
+
+// Verify checks the request's signature, timestamp and nonce, and returns
+// the principal of the signing key. The body is read and restored for the
+// handler. Rejections wrap model.ErrInvalidSignature; other errors come from
+// reading the body or from the nonce cache.
+func (v *Verifier) Verify(ctx context.Context, r *http.Request) (*model.Principal, error) {
+	params, err := signing.ParseAuthorization(r.Header.Get("Authorization"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidSignature, err)
+	}
+	if len(params.Nonce) > maxNonceLength {
+		return nil, fmt.Errorf("%w: nonce is too long", model.ErrInvalidSignature)
+	}
+	key, ok := v.keys[params.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key", model.ErrInvalidSignature)
+	}
+	if skew := v.now().Sub(params.Timestamp).Abs(); skew > v.opts.MaxSkew {
+		return nil, fmt.Errorf("%w: timestamp is outside the allowed clock skew", model.ErrInvalidSignature)
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err = io.ReadAll(http.MaxBytesReader(nil, r.Body, v.opts.MaxBodyBytes))
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	stringToSign := signing.StringToSign(r.Method, r.URL, params.Timestamp, params.Nonce, body)
+	if !signing.Valid(key.Secret, stringToSign, params.Signature) {
+		return nil, fmt.Errorf("%w: signature mismatch", model.ErrInvalidSignature)
+	}
+
+	// Nonces are only recorded for valid signatures, so forged requests
+	// cannot use up a client's nonces. They are kept for the whole window
+	// in which their timestamp would be accepted.
+	fresh, err := v.nonces.Add(ctx, key.ID+":"+params.Nonce, 2*v.opts.MaxSkew)
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, fmt.Errorf("%w: nonce was already used", model.ErrInvalidSignature)
+	}
+
+	return &model.Principal{
+		Subject: key.ID,
+		Method:  model.AuthMethodSignature,
+		Scopes:  key.Scopes,
+		Roles:   key.Roles,
+		Tenant:  key.Tenant,
+	}, nil
+}
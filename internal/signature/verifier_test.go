@@ -0,0 +1,142 @@
+package signature
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cruder/internal/model"
+	"cruder/pkg/signing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var testKey = Key{ID: "billing", Secret: []byte("0123456789abcdef0123456789abcdef"), Scopes: []string{model.ScopeUsersRead}}
+
+func signed(t *testing.T, method, target, body string, creds signing.Credentials) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if err := signing.Sign(req, creds); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return req
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	v := NewVerifier([]Key{testKey}, NewMemoryNonceCache(), Options{MaxSkew: time.Minute})
+	creds := signing.Credentials{KeyID: testKey.ID, Secret: testKey.Secret}
+
+	req := signed(t, http.MethodPost, "/api/v1/users/?b=2&a=1", `{"username":"jdoe"}`, creds)
+	principal, err := v.Verify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	if principal.Subject != "billing" || principal.Method != model.AuthMethodSignature || !principal.HasScope(model.ScopeUsersRead) {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+
+	replay := httptest.NewRequest(http.MethodPost, "/api/v1/users/?a=1&b=2", strings.NewReader(`{"username":"jdoe"}`))
+	replay.Header.Set("Authorization", req.Header.Get("Authorization"))
+	if _, err := v.Verify(context.Background(), replay); !errors.Is(err, model.ErrInvalidSignature) || !strings.Contains(err.Error(), "nonce") {
+		t.Errorf("expected the replay to be rejected, got %v", err)
+	}
+
+	tampered := signed(t, http.MethodPost, "/api/v1/users/", `{"username":"jdoe"}`, creds)
+	tampered.Body = io.NopCloser(strings.NewReader(`{"username":"root"}`))
+
+	cases := map[string]*http.Request{
+		"tampered body": tampered,
+		"unknown key":   signed(t, http.MethodGet, "/api/v1/users/", "", signing.Credentials{KeyID: "other", Secret: testKey.Secret}),
+		"wrong secret":  signed(t, http.MethodGet, "/api/v1/users/", "", signing.Credentials{KeyID: testKey.ID, Secret: []byte("wrong")}),
+		"malformed":     httptest.NewRequest(http.MethodGet, "/api/v1/users/", nil),
+	}
+	cases["malformed"].Header.Set("Authorization", "HMAC-SHA256 keyId=billing")
+	for name, req := range cases {
+		if _, err := v.Verify(context.Background(), req); !errors.Is(err, model.ErrInvalidSignature) {
+			t.Errorf("%s: expected an invalid signature, got %v", name, err)
+		}
+	}
+
+	v.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := v.Verify(context.Background(), signed(t, http.MethodGet, "/api/v1/users/", "", creds)); !errors.Is(err, model.ErrInvalidSignature) {
+		t.Errorf("expected a stale timestamp to be rejected, got %v", err)
+	}
+}
+
+func TestVerifier_TransportAndSharedNonces(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	v := NewVerifier([]Key{testKey}, NewRedisNonceCache(client, "test:"), Options{})
+
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := v.Verify(r.Context(), r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		seen = append(seen, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	httpClient := &http.Client{Transport: &signing.Transport{Credentials: signing.Credentials{KeyID: testKey.ID, Secret: testKey.Secret}}}
+	for i := 0; i < 2; i++ {
+		resp, err := httpClient.Post(srv.URL+"/api/v1/users/", "application/json", strings.NewReader(`{"username":"jdoe"}`))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("request %d: expected 200, got %d", i, resp.StatusCode)
+		}
+	}
+	if len(seen) != 2 || seen[0] == seen[1] {
+		t.Fatalf("expected two requests with distinct signatures, got %v", seen)
+	}
+
+	replay, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/users/", strings.NewReader(`{"username":"jdoe"}`))
+	replay.Header.Set("Authorization", seen[0])
+	resp, err := http.DefaultClient.Do(replay)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the replay to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestVerifier_CapsBody(t *testing.T) {
+	v := NewVerifier([]Key{testKey}, NewMemoryNonceCache(), Options{MaxBodyBytes: 16})
+	creds := signing.Credentials{KeyID: testKey.ID, Secret: testKey.Secret}
+
+	if _, err := v.Verify(context.Background(), signed(t, http.MethodPost, "/api/v1/users/", `{"a":1}`, creds)); err != nil {
+		t.Errorf("expected a small body to verify, got %v", err)
+	}
+
+	var tooLarge *http.MaxBytesError
+	req := signed(t, http.MethodPost, "/api/v1/users/", strings.Repeat("x", 17), creds)
+	if _, err := v.Verify(context.Background(), req); !errors.As(err, &tooLarge) {
+		t.Errorf("expected *http.MaxBytesError, got %v", err)
+	}
+}
+
+func TestVerifier_RejectsParametersThatAreNotTokens(t *testing.T) {
+	v := NewVerifier([]Key{testKey}, NewMemoryNonceCache(), Options{})
+	for _, header := range []string{
+		`HMAC-SHA256 keyId="billing\n", timestamp="1760000000", nonce="abc", signature="00"`,
+		`HMAC-SHA256 keyId="billing", timestamp="1760000000", nonce="a:b", signature="00"`,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/", nil)
+		req.Header.Set("Authorization", header)
+		if _, err := v.Verify(context.Background(), req); !errors.Is(err, model.ErrInvalidSignature) || !strings.Contains(err.Error(), signing.ErrMalformed.Error()) {
+			t.Errorf("expected %s to be rejected as malformed, got %v", header, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package tenancy
+
+import (
+	"context"
+
+	"cruder/internal/model"
+)
+
+type tenantKey struct{}
+
+// WithTenant scopes the operations made with ctx to tenant.
+func WithTenant(ctx context.Context, tenant model.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func FromContext(ctx context.Context) (model.Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(model.Tenant)
+	return tenant, ok
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import "testing"
+
+func TestSanitizeStatement(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      string
+	}{
+		{
+			name:      "string literals",
+			statement: `SELECT id FROM users WHERE email = 'jdoe@example.com' AND name = 'O''Brien'`,
+			want:      `SELECT id FROM users WHERE email = ? AND name = ?`,
+		},
+		{
+			name:      "numbers",
+			statement: `SELECT id FROM users WHERE id = 42 AND score > 3.5 LIMIT 10`,
+			want:      `SELECT id FROM users WHERE id = ? AND score > ? LIMIT ?`,
+		},
+		{
+			name:      "placeholders",
+			statement: `SELECT id FROM users WHERE tenant_id = $1 AND uuid = $12`,
+			want:      `SELECT id FROM users WHERE tenant_id = $1 AND uuid = $12`,
+		},
+		{
+			name:      "quoted identifiers",
+			statement: `SELECT "order-2", "it's" FROM "table 1" WHERE "col""3" = 7`,
+			want:      `SELECT "order-2", "it's" FROM "table 1" WHERE "col""3" = ?`,
+		},
+		{
+			name:      "digits in identifiers",
+			statement: `SELECT t1.col2 FROM users2 t1 WHERE t1.id = 5`,
+			want:      `SELECT t1.col2 FROM users2 t1 WHERE t1.id = ?`,
+		},
+		{
+			name:      "quotes and digits inside strings",
+			statement: `UPDATE users SET full_name = 'say "5" twice' WHERE id = $1`,
+			want:      `UPDATE users SET full_name = ? WHERE id = $1`,
+		},
+		{
+			name: "whitespace",
+			statement: `
+				SELECT id
+				FROM users   WHERE id = 1
+			`,
+			want: `SELECT id FROM users WHERE id = ?`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeStatement(tt.statement); got != tt.want {
+				t.Errorf("SanitizeStatement() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
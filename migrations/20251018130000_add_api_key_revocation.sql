@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_name_key;
+ALTER TABLE api_keys ADD COLUMN revoked_at TIMESTAMP;
+ALTER TABLE api_keys ADD COLUMN rotated_from INTEGER REFERENCES api_keys (id);
+CREATE INDEX IF NOT EXISTS api_keys_name_idx ON api_keys (name);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS api_keys_name_idx;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS rotated_from;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_name_key UNIQUE (name);
+-- +goose StatementEnd
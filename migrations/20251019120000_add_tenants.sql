@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(63) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO tenants (slug, name) VALUES ('default', 'Default');
+
+ALTER TABLE users ADD COLUMN tenant_id INTEGER REFERENCES tenants (id);
+UPDATE users SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_username_key UNIQUE (tenant_id, username);
+ALTER TABLE users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);
+
+ALTER TABLE api_keys ADD COLUMN tenant VARCHAR(63) REFERENCES tenants (slug);
+
+-- Defense in depth for the tenant filter in every query: sessions only see
+-- and write the rows of the tenant in app.tenant_id, and none at all when it
+-- is unset. The policy binds the application role, which must not own the
+-- table, be a superuser or have BYPASSRLS. Migrations run as the owner and
+-- are not restricted.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+CREATE POLICY users_tenant_isolation ON users
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::integer);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_email_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_username_key;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
+-- +goose StatementEnd
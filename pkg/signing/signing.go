@@ -0,0 +1,215 @@
+// Package signing implements the HMAC request signature scheme accepted by
+// the API, so services can authenticate without sending a reusable secret.
+//
+// A signed request carries
+//
+//	Authorization: HMAC-SHA256 keyId="billing", timestamp="1760000000", nonce="...", signature="..."
+//
+// where signature is the hex HMAC-SHA256, under the key's shared secret, of
+// the string built by StringToSign from the method, path, query, timestamp,
+// nonce and the SHA-256 of the body.
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Scheme = "HMAC-SHA256"
+
+var ErrMalformed = errors.New("malformed signature header")
+
+type Credentials struct {
+	KeyID  string
+	Secret []byte
+}
+
+// Parameters are the fields of a signature Authorization header.
+type Parameters struct {
+	KeyID     string
+	Timestamp time.Time
+	Nonce     string
+	Signature string
+}
+
+// String formats p as an Authorization header. The key ID and nonce are
+// written as they are, so p should pass Validate first.
+func (p Parameters) String() string {
+	return fmt.Sprintf(`%s keyId="%s", timestamp="%d", nonce="%s", signature="%s"`,
+		Scheme, p.KeyID, p.Timestamp.Unix(), p.Nonce, p.Signature)
+}
+
+// Validate checks that the key ID and nonce are tokens and that there is a
+// signature.
+func (p Parameters) Validate() error {
+	if !ValidToken(p.KeyID) || !ValidToken(p.Nonce) || p.Signature == "" {
+		return ErrMalformed
+	}
+	return nil
+}
+
+// ValidToken reports whether s may be used as a key ID or nonce: letters,
+// digits and -._~ only, so it can be quoted in the header without escaping.
+func ValidToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// IsSigned reports whether an Authorization header uses this scheme.
+func IsSigned(header string) bool {
+	scheme, _, _ := strings.Cut(header, " ")
+	return strings.EqualFold(scheme, Scheme)
+}
+
+func ParseAuthorization(header string) (Parameters, error) {
+	scheme, rest, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, Scheme) {
+		return Parameters{}, ErrMalformed
+	}
+
+	fields := make(map[string]string)
+	for _, part := range strings.Split(rest, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return Parameters{}, ErrMalformed
+		}
+		fields[name] = strings.Trim(value, `"`)
+	}
+
+	seconds, err := strconv.ParseInt(fields["timestamp"], 10, 64)
+	if err != nil {
+		return Parameters{}, ErrMalformed
+	}
+	p := Parameters{
+		KeyID:     fields["keyId"],
+		Timestamp: time.Unix(seconds, 0),
+		Nonce:     fields["nonce"],
+		Signature: fields["signature"],
+	}
+	if err := p.Validate(); err != nil {
+		return Parameters{}, err
+	}
+	return p, nil
+}
+
+// StringToSign is the canonical form of a request that gets signed. The
+// query parameters are sorted so their order does not matter.
+func StringToSign(method string, u *url.URL, timestamp time.Time, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{
+		Scheme,
+		strings.ToUpper(method),
+		u.EscapedPath(),
+		canonicalQuery(u.Query()),
+		strconv.FormatInt(timestamp.Unix(), 10),
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+}
+
+func Signature(secret []byte, stringToSign string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Valid compares a received signature with the expected one in constant
+// time.
+func Valid(secret []byte, stringToSign, signature string) bool {
+	expected := Signature(secret, stringToSign)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// Sign sets the Authorization header of req. The body is read and replaced,
+// so the request can still be sent.
+func Sign(req *http.Request, creds Credentials) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	p := Parameters{
+		KeyID:     creds.KeyID,
+		Timestamp: time.Now(),
+		Nonce:     hex.EncodeToString(nonce),
+	}
+	p.Signature = Signature(creds.Secret, StringToSign(req.Method, req.URL, p.Timestamp, p.Nonce, body))
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("%w: key id %q is not a token", err, creds.KeyID)
+	}
+	req.Header.Set("Authorization", p.String())
+	return nil
+}
+
+// Transport signs every request before passing it to Base, or to
+// http.DefaultTransport when Base is nil. Each attempt gets a fresh nonce,
+// so retries are not rejected as replays.
+type Transport struct {
+	Base        http.RoundTripper
+	Credentials Credentials
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request.
+	req = req.Clone(req.Context())
+	if err := Sign(req, t.Credentials); err != nil {
+		return nil, err
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		vals := append([]string(nil), values[key]...)
+		sort.Strings(vals)
+		for _, value := range vals {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
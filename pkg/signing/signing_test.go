@@ -0,0 +1,138 @@
+package signing
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testCreds = Credentials{KeyID: "billing", Secret: []byte("0123456789abcdef0123456789abcdef")}
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "b=2&a=1", want: "a=1&b=2"},
+		{query: "a=2&a=1&b=", want: "a=1&a=2&b="},
+		{query: "q=a+b&q=a%20c", want: "q=a+b&q=a+c"},
+		{query: "k%3Dx=v%26w", want: "k%3Dx=v%26w"},
+	}
+
+	for _, tt := range tests {
+		values, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := canonicalQuery(values); got != tt.want {
+			t.Errorf("canonicalQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	a, _ := url.Parse("/users/?b=2&a=1")
+	b, _ := url.Parse("/users/?a=1&b=2")
+	at := time.Unix(1760000000, 0)
+	if StringToSign(http.MethodGet, a, at, "n", nil) != StringToSign(http.MethodGet, b, at, "n", nil) {
+		t.Error("expected the query order not to change the string to sign")
+	}
+}
+
+func TestParseAuthorization(t *testing.T) {
+	p := Parameters{KeyID: "billing", Timestamp: time.Unix(1760000000, 0), Nonce: "0a1b2c", Signature: "abcdef"}
+	parsed, err := ParseAuthorization(p.String())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if parsed != p {
+		t.Errorf("expected %+v, got %+v", p, parsed)
+	}
+
+	for _, header := range []string{
+		`Bearer token`,
+		`HMAC-SHA256 keyId="billing", timestamp="soon", nonce="n", signature="s"`,
+		`HMAC-SHA256 keyId="", timestamp="1760000000", nonce="n", signature="s"`,
+		`HMAC-SHA256 keyId="bill ing", timestamp="1760000000", nonce="n", signature="s"`,
+		`HMAC-SHA256 keyId="billing", timestamp="1760000000", nonce="n\"", signature="s"`,
+		`HMAC-SHA256 keyId="billing", timestamp="1760000000", nonce="n", signature=""`,
+	} {
+		if _, err := ParseAuthorization(header); !errors.Is(err, ErrMalformed) {
+			t.Errorf("expected ErrMalformed for %s, got %v", header, err)
+		}
+	}
+}
+
+func TestSign_RejectsKeyIDsThatAreNotTokens(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	creds := Credentials{KeyID: `billing", keyId="admin`, Secret: testCreds.Secret}
+	if err := Sign(req, creds); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("expected no Authorization header")
+	}
+}
+
+// verify checks r the way the server does and returns its nonce.
+func verify(r *http.Request) (string, error) {
+	p, err := ParseAuthorization(r.Header.Get("Authorization"))
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	if !Valid(testCreds.Secret, StringToSign(r.Method, r.URL, p.Timestamp, p.Nonce, body), p.Signature) {
+		return "", errors.New("signature mismatch")
+	}
+	return p.Nonce, nil
+}
+
+func TestTransport_SignsEachAttempt(t *testing.T) {
+	var mu sync.Mutex
+	var nonces []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce, err := verify(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		nonces = append(nonces, nonce)
+		mu.Unlock()
+		// The first attempt is redirected, so the client sends the body
+		// again through the transport.
+		if r.URL.Query().Get("attempt") == "" {
+			http.Redirect(w, r, "/users/?attempt=2", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/users/", strings.NewReader(`{"username":"jdoe"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &Transport{Credentials: testCreds}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if len(nonces) != 2 || nonces[0] == nonces[1] {
+		t.Errorf("expected both attempts to be signed with distinct nonces, got %v", nonces)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("expected the caller's request to be left unsigned")
+	}
+}
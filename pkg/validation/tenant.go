@@ -0,0 +1,32 @@
+package validation
+
+import (
+	"regexp"
+
+	"cruder/internal/model"
+)
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func ValidateTenantSlug(slug string) error {
+	if slug == "" {
+		return model.NewRuleValidationError("tenant_slug_required", "tenant slug is required")
+	}
+	if !tenantSlugPattern.MatchString(slug) {
+		return model.NewRuleValidationError("tenant_slug_format", "tenant slug must be lowercase letters, digits and dashes")
+	}
+	return nil
+}
+
+func ValidateCreateTenantInput(slug, name string) error {
+	if err := ValidateTenantSlug(slug); err != nil {
+		return err
+	}
+	if name == "" {
+		return model.NewRuleValidationError("tenant_name_required", "tenant name is required")
+	}
+	if len(name) > 100 {
+		return model.NewRuleValidationError("tenant_name_length", "tenant name is too long")
+	}
+	return nil
+}
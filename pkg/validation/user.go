@@ -14,47 +14,47 @@ var (
 
 func ValidateUsername(username string) error {
 	if username == "" {
-		return model.NewValidationError("username is required")
+		return model.NewRuleValidationError("username_required", "username is required")
 	}
 	if !usernamePattern.MatchString(username) {
-		return model.NewValidationError("username is invalid")
+		return model.NewRuleValidationError("username_format", "username is invalid")
 	}
 	return nil
 }
 
 func ValidateEmail(email string) error {
 	if email == "" {
-		return model.NewValidationError("email is required")
+		return model.NewRuleValidationError("email_required", "email is required")
 	}
 	if !emailPattern.MatchString(email) {
-		return model.NewValidationError("email is invalid")
+		return model.NewRuleValidationError("email_format", "email is invalid")
 	}
 	return nil
 }
 
 func ValidateFullName(fullName string) error {
 	if fullName == "" {
-		return model.NewValidationError("full name is required")
+		return model.NewRuleValidationError("full_name_required", "full name is required")
 	}
 	if len(fullName) > 100 {
-		return model.NewValidationError("full name is too long")
+		return model.NewRuleValidationError("full_name_length", "full name is too long")
 	}
 	return nil
 }
 
 func ValidateUUID(value string) error {
 	if value == "" {
-		return model.NewValidationError("uuid is required")
+		return model.NewRuleValidationError("uuid_required", "uuid is required")
 	}
 	if !uuidPattern.MatchString(value) {
-		return model.NewValidationError("uuid is invalid")
+		return model.NewRuleValidationError("uuid_format", "uuid is invalid")
 	}
 	return nil
 }
 
 func ValidateID(id int64) error {
 	if id < 1 {
-		return model.NewValidationError("id must be positive")
+		return model.NewRuleValidationError("id_positive", "id must be positive")
 	}
 	return nil
 }
@@ -74,7 +74,7 @@ func ValidateCreateUserInput(username, email, fullName string) error {
 
 func ValidateUpdateUserInput(username, email, fullName string) error {
 	if username == "" && email == "" && fullName == "" {
-		return model.NewValidationError("no fields to update")
+		return model.NewRuleValidationError("update_fields_required", "no fields to update")
 	}
 	if username != "" {
 		if err := ValidateUsername(username); err != nil {
@@ -249,8 +249,8 @@ resource "aws_lb_target_group" "app" {
     unhealthy_threshold = 2
     timeout             = 3
     interval            = 30
-    path                = "/api/v1/users"
-    matcher             = "200,401,403"
+    path                = "/readyz"
+    matcher             = "200"
   }
 
   tags = {
@@ -306,9 +306,10 @@ resource "aws_ecs_task_definition" "app" {
 
   container_definitions = jsonencode([
     {
-      name      = "cruder"
-      image     = "${var.ecr_repository_url}:${var.app_version}"
-      essential = true
+      name        = "cruder"
+      image       = "${var.ecr_repository_url}:${var.app_version}"
+      essential   = true
+      stopTimeout = 40
       portMappings = [
         {
           containerPort = 8080